@@ -164,7 +164,7 @@ func demonstrateTTLStrategies() {
 	decaying := &chain.DecayingTTLStrategy{DecayFactor: 0.5}
 	fmt.Println("  Decaying Strategy (0.5 factor):")
 	for i := 0; i < 3; i++ {
-		ttl := decaying.GetTTL(i, baseTTL)
+		ttl := decaying.TTLFor(chain.TTLContext{LayerIndex: i, LayerCount: 3}, baseTTL)
 		fmt.Printf("    Layer %d: %v\n", i, ttl)
 	}
 
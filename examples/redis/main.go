@@ -77,7 +77,7 @@ func main() {
 	}
 
 	start := time.Now()
-	if err := redisCache.BatchSet(ctx, users, time.Hour); err != nil {
+	if err := redisCache.SetMulti(ctx, users, time.Hour); err != nil {
 		log.Fatalf("Failed to batch set: %v", err)
 	}
 	batchSetDuration := time.Since(start)
@@ -87,7 +87,7 @@ func main() {
 	keys := []string{"user:1", "user:2", "user:3", "user:4", "user:5"}
 	
 	start = time.Now()
-	results, err := redisCache.BatchGet(ctx, keys)
+	results, err := redisCache.GetMulti(ctx, keys)
 	if err != nil {
 		log.Fatalf("Failed to batch get: %v", err)
 	}
@@ -97,7 +97,7 @@ func main() {
 
 	// Batch Delete
 	start = time.Now()
-	if err := redisCache.BatchDelete(ctx, keys); err != nil {
+	if err := redisCache.DeleteMulti(ctx, keys); err != nil {
 		log.Fatalf("Failed to batch delete: %v", err)
 	}
 	batchDeleteDuration := time.Since(start)
@@ -194,7 +194,7 @@ func main() {
 		"config:retries":  3,
 	}
 
-	if err := redisCache.BatchSet(ctx, testData, time.Hour); err != nil {
+	if err := redisCache.SetMulti(ctx, testData, time.Hour); err != nil {
 		log.Fatalf("Failed to set test data: %v", err)
 	}
 
@@ -240,7 +240,7 @@ func main() {
 	}
 
 	batchStart := time.Now()
-	redisCache.BatchSet(ctx, batchData, time.Minute)
+	redisCache.SetMulti(ctx, batchData, time.Minute)
 	batchDuration := time.Since(batchStart)
 	fmt.Printf("Batch Set (10 ops):  %v\n", batchDuration)
 
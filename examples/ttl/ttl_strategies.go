@@ -87,9 +87,10 @@ func demonstrateDecayingStrategy() {
 	}
 
 	fmt.Printf("  ✓ Set 'session:xyz' with %v base TTL\n", baseTTL)
-	fmt.Printf("  → L1: %v\n", strategy.GetTTL(0, baseTTL))
-	fmt.Printf("  → L2: %v\n", strategy.GetTTL(1, baseTTL))
-	fmt.Printf("  → L3: %v\n", strategy.GetTTL(2, baseTTL))
+	for i := 0; i < 3; i++ {
+		ttl := strategy.TTLFor(chain.TTLContext{LayerIndex: i, LayerCount: 3}, baseTTL)
+		fmt.Printf("  → L%d: %v\n", i+1, ttl)
+	}
 	fmt.Println("  (Exponential decay: L1 expires first, L3 lasts longest)")
 	fmt.Println()
 }
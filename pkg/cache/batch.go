@@ -20,14 +20,57 @@ type BatchCacheLayer interface {
 	DeleteMulti(ctx context.Context, keys []string) error
 }
 
+// BatchEntryLayer is implemented by batch layers that can also read several
+// entries with their metadata at once, e.g. to learn how long values found
+// in a lower layer have left before promoting them.
+type BatchEntryLayer interface {
+	BatchCacheLayer
+
+	// GetMultiEntries is GetMulti returning entries, filled in like
+	// EntryLayer.GetEntry does. Missing keys are absent from the result.
+	GetMultiEntries(ctx context.Context, keys []string) (map[string]*CacheEntry, error)
+}
+
+// BatchAdapterConfig configures a BatchAdapter.
+type BatchAdapterConfig struct {
+	// MaxWorkers bounds the concurrent calls to the wrapped layer
+	// (optional, defaults to 16)
+	MaxWorkers int
+
+	// ChunkSize is the most keys a worker takes at a time; smaller
+	// batches are spread over the workers a few keys at a time
+	// (optional, defaults to 64)
+	ChunkSize int
+}
+
 // BatchAdapter wraps a non-batch layer with batch operations.
+// Keys are handed out in chunks to a bounded pool of workers, so large
+// batches don't flood the layer with goroutines while small ones still run
+// in parallel.
 type BatchAdapter struct {
-	layer CacheLayer
+	layer      CacheLayer
+	maxWorkers int
+	chunkSize  int
 }
 
-// NewBatchAdapter creates a new batch adapter.
+// NewBatchAdapter creates a new batch adapter with default limits.
 func NewBatchAdapter(layer CacheLayer) *BatchAdapter {
-	return &BatchAdapter{layer: layer}
+	return NewBatchAdapterWithConfig(layer, BatchAdapterConfig{})
+}
+
+// NewBatchAdapterWithConfig creates a new batch adapter with custom limits.
+func NewBatchAdapterWithConfig(layer CacheLayer, config BatchAdapterConfig) *BatchAdapter {
+	if config.MaxWorkers <= 0 {
+		config.MaxWorkers = 16
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 64
+	}
+	return &BatchAdapter{
+		layer:      layer,
+		maxWorkers: config.MaxWorkers,
+		chunkSize:  config.ChunkSize,
+	}
 }
 
 // Name returns the name of the underlying cache layer.
@@ -55,104 +98,134 @@ func (ba *BatchAdapter) Close() error {
 	return ba.layer.Close()
 }
 
-// GetMulti retrieves multiple keys in parallel.
+// GetMulti retrieves multiple keys in parallel. Missing keys are absent from
+// the result. Keys that fail with any other error are reported in a
+// *BatchError returned along with the keys that were found.
 func (ba *BatchAdapter) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
-	results := make(map[string]interface{})
+	results := make(map[string]interface{}, len(keys))
 	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, key := range keys {
-		wg.Add(1)
-		go func(k string) {
-			defer wg.Done()
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
 
-			if value, err := ba.layer.Get(ctx, k); err == nil {
-				mu.Lock()
-				results[k] = value
-				mu.Unlock()
+	err := ba.run(ctx, keys, func(key string) error {
+		value, err := ba.layer.Get(ctx, key)
+		if err != nil {
+			if IsNotFound(err) {
+				return nil
 			}
-		}(key)
-	}
-
-	wg.Wait()
-
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
-	}
-
-	return results, nil
+			return err
+		}
+		mu.Lock()
+		results[key] = value
+		mu.Unlock()
+		return nil
+	})
+
+	return results, err
 }
 
-// SetMulti stores multiple key-value pairs in parallel.
-func (ba *BatchAdapter) SetMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
-	var wg sync.WaitGroup
+// GetMultiEntries retrieves multiple entries in parallel, like GetMulti.
+// Entries of layers that don't implement EntryLayer carry no metadata.
+func (ba *BatchAdapter) GetMultiEntries(ctx context.Context, keys []string) (map[string]*CacheEntry, error) {
+	results := make(map[string]*CacheEntry, len(keys))
 	var mu sync.Mutex
-	var lastErr error
 
-	for key, value := range items {
-		wg.Add(1)
-		go func(k string, v interface{}) {
-			defer wg.Done()
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
+	el, hasEntries := ba.layer.(EntryLayer)
+	err := ba.run(ctx, keys, func(key string) error {
+		var entry *CacheEntry
+		var err error
+		if hasEntries {
+			entry, err = el.GetEntry(ctx, key)
+		} else {
+			var value interface{}
+			value, err = ba.layer.Get(ctx, key)
+			entry = &CacheEntry{Key: key, Value: value}
+		}
+		if err != nil {
+			if IsNotFound(err) {
+				return nil
 			}
+			return err
+		}
+		mu.Lock()
+		results[key] = entry
+		mu.Unlock()
+		return nil
+	})
+
+	return results, err
+}
 
-			if err := ba.layer.Set(ctx, k, v, ttl); err != nil {
-				mu.Lock()
-				lastErr = err
-				mu.Unlock()
-			}
-		}(key, value)
-	}
-
-	wg.Wait()
-
-	if ctx.Err() != nil {
-		return ctx.Err()
+// SetMulti stores multiple key-value pairs in parallel.
+// Keys that fail are reported in a *BatchError; the others are stored.
+func (ba *BatchAdapter) SetMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
 	}
 
-	return lastErr
+	return ba.run(ctx, keys, func(key string) error {
+		return ba.layer.Set(ctx, key, items[key], ttl)
+	})
 }
 
 // DeleteMulti removes multiple keys in parallel.
+// Keys that fail are reported in a *BatchError; the others are deleted.
 func (ba *BatchAdapter) DeleteMulti(ctx context.Context, keys []string) error {
-	var wg sync.WaitGroup
+	return ba.run(ctx, keys, func(key string) error {
+		return ba.layer.Delete(ctx, key)
+	})
+}
+
+// run applies op to every key with up to maxWorkers workers (one per key
+// for small batches). Keys are handed out in chunks of at most chunkSize,
+// small enough that every worker gets some. Per-key failures are collected
+// into a *BatchError. If ctx is done, the remaining keys are skipped and
+// ctx.Err() is returned.
+func (ba *BatchAdapter) run(ctx context.Context, keys []string, op func(key string) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	chunks := make(chan []string)
+	workers := min(ba.maxWorkers, len(keys))
+	chunkSize := min(ba.chunkSize, (len(keys)+workers-1)/workers)
+
 	var mu sync.Mutex
-	var lastErr error
+	var batchErr BatchError
+	var wg sync.WaitGroup
 
-	for _, key := range keys {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(k string) {
+		go func() {
 			defer wg.Done()
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-
-			if err := ba.layer.Delete(ctx, k); err != nil {
-				mu.Lock()
-				lastErr = err
-				mu.Unlock()
+			for chunk := range chunks {
+				for _, key := range chunk {
+					if ctx.Err() != nil {
+						break
+					}
+					if err := op(key); err != nil {
+						mu.Lock()
+						batchErr.Add(key, err)
+						mu.Unlock()
+					}
+				}
 			}
-		}(key)
+		}()
 	}
 
+feed:
+	for start := 0; start < len(keys); start += chunkSize {
+		end := min(start+chunkSize, len(keys))
+		select {
+		case chunks <- keys[start:end]:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(chunks)
 	wg.Wait()
 
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
-
-	return lastErr
+	return batchErr.Err()
 }
@@ -2,12 +2,15 @@ package batch
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"cache-chain/pkg/cache"
 	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
 )
 
 func TestBatchAdapter_SetMulti(t *testing.T) {
@@ -90,6 +93,34 @@ func TestBatchAdapter_GetMulti(t *testing.T) {
 	}
 }
 
+func TestBatchAdapter_GetMultiEntries(t *testing.T) {
+	base := memory.NewMemoryCache(memory.MemoryCacheConfig{
+		Name:    "test",
+		MaxSize: 100,
+	})
+	batch := cache.NewBatchAdapter(base)
+	defer batch.Close()
+
+	ctx := context.Background()
+	batch.Set(ctx, "key1", "value1", time.Hour)
+
+	results, err := batch.GetMultiEntries(ctx, []string{"key1", "key-missing"})
+	if err != nil {
+		t.Fatalf("GetMultiEntries failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	entry := results["key1"]
+	if entry.Value != "value1" {
+		t.Errorf("Expected 'value1', got %v", entry.Value)
+	}
+	if remaining := time.Until(entry.ExpiresAt); remaining <= 0 || remaining > time.Hour {
+		t.Errorf("Expected the entry to expire within an hour, got %v", remaining)
+	}
+}
+
 func TestBatchAdapter_DeleteMulti(t *testing.T) {
 	base := memory.NewMemoryCache(memory.MemoryCacheConfig{
 		Name:    "test",
@@ -299,3 +330,130 @@ func TestBatchAdapter_PartialFailures(t *testing.T) {
 		t.Error("Missing key3")
 	}
 }
+
+func TestBatchAdapter_MaxWorkers(t *testing.T) {
+	var inFlight, maxInFlight int64
+	base := mock.NewMockLayer("slow")
+	base.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return key, nil
+	}
+
+	batch := cache.NewBatchAdapterWithConfig(base, cache.BatchAdapterConfig{
+		MaxWorkers: 3,
+		ChunkSize:  5,
+	})
+
+	keys := make([]string, 60)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	results, err := batch.GetMulti(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("GetMulti failed: %v", err)
+	}
+	if len(results) != len(keys) {
+		t.Errorf("Expected %d results, got %d", len(keys), len(results))
+	}
+	if base.GetCalls() != len(keys) {
+		t.Errorf("Expected %d Get calls, got %d", len(keys), base.GetCalls())
+	}
+	if max := atomic.LoadInt64(&maxInFlight); max > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", max)
+	}
+}
+
+func TestBatchAdapter_SmallBatchParallel(t *testing.T) {
+	var inFlight, maxInFlight int64
+	base := mock.NewMockLayer("slow")
+	base.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return key, nil
+	}
+
+	// 8 keys, well under the default ChunkSize
+	batch := cache.NewBatchAdapterWithConfig(base, cache.BatchAdapterConfig{MaxWorkers: 4})
+
+	keys := make([]string, 8)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	results, err := batch.GetMulti(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("GetMulti failed: %v", err)
+	}
+	if len(results) != len(keys) {
+		t.Errorf("Expected %d results, got %d", len(keys), len(results))
+	}
+	if max := atomic.LoadInt64(&maxInFlight); max != 4 {
+		t.Errorf("Expected 4 concurrent calls, got %d", max)
+	}
+}
+
+func TestBatchAdapter_PerKeyErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	base := mock.NewMockLayer("flaky")
+	base.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		switch key {
+		case "bad1", "bad2":
+			return nil, errBoom
+		case "missing":
+			return nil, cache.ErrKeyNotFound
+		}
+		return "v-" + key, nil
+	}
+	base.SetFunc = func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+		if key == "bad1" {
+			return errBoom
+		}
+		return nil
+	}
+
+	batch := cache.NewBatchAdapterWithConfig(base, cache.BatchAdapterConfig{ChunkSize: 2})
+	ctx := context.Background()
+
+	results, err := batch.GetMulti(ctx, []string{"a", "bad1", "b", "missing", "bad2"})
+	batchErr, ok := cache.AsBatchError(err)
+	if !ok {
+		t.Fatalf("Expected *BatchError, got %v", err)
+	}
+	if keys := batchErr.Keys(); len(keys) != 2 || keys[0] != "bad1" || keys[1] != "bad2" {
+		t.Errorf("Expected failed keys [bad1 bad2], got %v", keys)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Error("Expected BatchError to wrap the per-key error")
+	}
+	if len(results) != 2 || results["a"] != "v-a" || results["b"] != "v-b" {
+		t.Errorf("Expected partial results for a and b, got %v", results)
+	}
+
+	err = batch.SetMulti(ctx, map[string]interface{}{"a": 1, "bad1": 2}, time.Hour)
+	batchErr, ok = cache.AsBatchError(err)
+	if !ok {
+		t.Fatalf("Expected *BatchError, got %v", err)
+	}
+	if keys := batchErr.Keys(); len(keys) != 1 || keys[0] != "bad1" {
+		t.Errorf("Expected failed keys [bad1], got %v", keys)
+	}
+	if base.SetCalls() != 2 {
+		t.Errorf("Expected 2 Set calls, got %d", base.SetCalls())
+	}
+}
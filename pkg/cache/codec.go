@@ -0,0 +1,419 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec serializes values for layers that store bytes (e.g. Redis).
+// The content type identifies the wire format and is written into the
+// header of every encoded value so readers can pick the matching codec.
+type Codec interface {
+	// Marshal encodes v into bytes.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes data into v, which must be a pointer.
+	Unmarshal(data []byte, v interface{}) error
+
+	// ContentType returns the identifier of the wire format (e.g. "application/json").
+	ContentType() string
+}
+
+// Content types of the built-in codecs.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeGob      = "application/x-gob"
+	ContentTypeMsgpack  = "application/msgpack"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Header layout: magic byte, header version, compression flag (version 2+),
+// content type length, content type, entry metadata (versions 3 and 4). The
+// magic byte can never start a JSON document, so values written before
+// headers were introduced are still recognized (and decoded as JSON).
+const (
+	headerMagic   byte = 0xCC
+	headerVersion byte = 2
+
+	// headerVersionEntry marks headers carrying entry metadata
+	headerVersionEntry byte = 4
+
+	// entryMetaSize is the size of the metadata block: expiresAt, staleAt,
+	// createdAt (Unix nanoseconds), version and load duration (nanoseconds),
+	// as big-endian int64s. Version 3 headers lack the load duration.
+	entryMetaSize   = 40
+	entryMetaSizeV3 = 32
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		ContentTypeJSON:    JSONCodec{},
+		ContentTypeGob:     GobCodec{},
+		ContentTypeMsgpack: MsgpackCodec{},
+	}
+)
+
+// RegisterCodec makes a codec available for decoding values by content type.
+// Registering a codec with an existing content type replaces it.
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[codec.ContentType()] = codec
+}
+
+// LookupCodec returns the registered codec for the given content type.
+func LookupCodec(contentType string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[contentType]
+	return codec, ok
+}
+
+// Encode marshals v with codec and prefixes the result with a header
+// identifying the codec.
+func Encode(codec Codec, v interface{}) ([]byte, error) {
+	data, _, err := EncodeCompressed(codec, CompressionConfig{}, v)
+	return data, err
+}
+
+// EncodeStats describes the sizes of a value encoded by EncodeCompressed.
+type EncodeStats struct {
+	// RawSize is the size of the codec output before compression
+	RawSize int
+
+	// StoredSize is the size of the payload after compression (excluding header)
+	StoredSize int
+
+	// Compression is the algorithm applied (CompressionNone if skipped)
+	Compression Compression
+}
+
+// EncodeCompressed is like Encode but compresses the payload when it reaches
+// the configured size threshold. Compression is skipped if it doesn't shrink
+// the payload. The algorithm used is recorded in the header.
+func EncodeCompressed(codec Codec, compression CompressionConfig, v interface{}) ([]byte, EncodeStats, error) {
+	return encode(codec, compression, v, nil)
+}
+
+// EncodeEntry is like EncodeCompressed but also stores the entry's metadata
+// (expiry, stale time, creation time, version and load duration) in the header, so layers
+// that only store bytes can return it from GetEntry. Values encoded this way
+// can still be read with Decode.
+func EncodeEntry(codec Codec, compression CompressionConfig, entry *CacheEntry) ([]byte, EncodeStats, error) {
+	return encode(codec, compression, entry.Value, entry)
+}
+
+// encode marshals and optionally compresses v, prefixing the header.
+// meta is written into the header when not nil.
+func encode(codec Codec, compression CompressionConfig, v interface{}, meta *CacheEntry) ([]byte, EncodeStats, error) {
+	payload, err := codec.Marshal(v)
+	if err != nil {
+		return nil, EncodeStats{}, err
+	}
+
+	stats := EncodeStats{RawSize: len(payload), StoredSize: len(payload)}
+
+	if compression.shouldCompress(len(payload)) {
+		compressed, err := Compress(compression.Algorithm, payload)
+		if err != nil {
+			return nil, EncodeStats{}, err
+		}
+		if len(compressed) < len(payload) {
+			payload = compressed
+			stats.StoredSize = len(compressed)
+			stats.Compression = compression.Algorithm
+		}
+	}
+
+	contentType := codec.ContentType()
+	if len(contentType) > 255 {
+		return nil, EncodeStats{}, fmt.Errorf("%w: content type too long", ErrInvalidValue)
+	}
+
+	version := headerVersion
+	if meta != nil {
+		version = headerVersionEntry
+	}
+
+	data := make([]byte, 0, 4+len(contentType)+entryMetaSize+len(payload))
+	data = append(data, headerMagic, version, byte(stats.Compression), byte(len(contentType)))
+	data = append(data, contentType...)
+	if meta != nil {
+		data = binary.BigEndian.AppendUint64(data, uint64(unixNano(meta.ExpiresAt)))
+		data = binary.BigEndian.AppendUint64(data, uint64(unixNano(meta.StaleAt)))
+		data = binary.BigEndian.AppendUint64(data, uint64(unixNano(meta.CreatedAt)))
+		data = binary.BigEndian.AppendUint64(data, uint64(meta.Version))
+		data = binary.BigEndian.AppendUint64(data, uint64(meta.LoadDuration))
+	}
+	data = append(data, payload...)
+	return data, stats, nil
+}
+
+// Decode reads the header written by Encode and unmarshals the payload into v.
+// codec is used when it matches the header's content type, otherwise the
+// registered codec for that content type is used. Data without a header is
+// decoded as JSON.
+func Decode(codec Codec, data []byte, v interface{}) error {
+	h, err := splitHeader(data)
+	if err != nil {
+		return err
+	}
+	return h.decode(codec, v)
+}
+
+// DecodeEntry decodes data into entry.Value and, if the value was written by
+// EncodeEntry, fills in the entry's metadata. Reports whether metadata was
+// present; when it wasn't, only Value is set.
+func DecodeEntry(codec Codec, data []byte, entry *CacheEntry) (bool, error) {
+	h, err := splitHeader(data)
+	if err != nil {
+		return false, err
+	}
+
+	var value interface{}
+	if err := h.decode(codec, &value); err != nil {
+		return false, err
+	}
+	entry.Value = value
+
+	if h.meta == nil {
+		return false, nil
+	}
+	entry.ExpiresAt = fromUnixNano(int64(binary.BigEndian.Uint64(h.meta[0:8])))
+	entry.StaleAt = fromUnixNano(int64(binary.BigEndian.Uint64(h.meta[8:16])))
+	entry.CreatedAt = fromUnixNano(int64(binary.BigEndian.Uint64(h.meta[16:24])))
+	entry.Version = int64(binary.BigEndian.Uint64(h.meta[24:32]))
+	if len(h.meta) >= entryMetaSize {
+		entry.LoadDuration = time.Duration(binary.BigEndian.Uint64(h.meta[32:40]))
+	}
+	return true, nil
+}
+
+// header is a parsed value header.
+type header struct {
+	contentType string
+	compression Compression
+	meta        []byte // entry metadata, nil if absent
+	payload     []byte
+}
+
+// decode decompresses and unmarshals the payload into v.
+// codec is used when it matches the content type, otherwise the registered one is.
+func (h header) decode(codec Codec, v interface{}) error {
+	if codec == nil || codec.ContentType() != h.contentType {
+		var ok bool
+		codec, ok = LookupCodec(h.contentType)
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCodec, h.contentType)
+		}
+	}
+
+	payload, err := Decompress(h.compression, h.payload)
+	if err != nil {
+		return err
+	}
+
+	return codec.Unmarshal(payload, v)
+}
+
+// splitHeader separates the content type, compression flag and entry
+// metadata from the payload.
+func splitHeader(data []byte) (header, error) {
+	if len(data) == 0 || data[0] != headerMagic {
+		return header{contentType: ContentTypeJSON, payload: data}, nil
+	}
+
+	if len(data) < 3 {
+		return header{}, fmt.Errorf("%w: truncated codec header", ErrInvalidValue)
+	}
+
+	// Version 1 headers predate compression and have no flag byte
+	h := header{}
+	offset := 2
+	switch data[1] {
+	case 1:
+	case headerVersion, 3, headerVersionEntry:
+		h.compression = Compression(data[2])
+		offset = 3
+	default:
+		return header{}, fmt.Errorf("%w: unsupported codec header version %d", ErrInvalidValue, data[1])
+	}
+
+	if len(data) <= offset {
+		return header{}, fmt.Errorf("%w: truncated codec header", ErrInvalidValue)
+	}
+
+	end := offset + 1 + int(data[offset])
+	if len(data) < end {
+		return header{}, fmt.Errorf("%w: truncated codec header", ErrInvalidValue)
+	}
+	h.contentType = string(data[offset+1 : end])
+
+	metaSize := 0
+	switch data[1] {
+	case 3:
+		metaSize = entryMetaSizeV3
+	case headerVersionEntry:
+		metaSize = entryMetaSize
+	}
+	if metaSize > 0 {
+		if len(data) < end+metaSize {
+			return header{}, fmt.Errorf("%w: truncated entry metadata", ErrInvalidValue)
+		}
+		h.meta = data[end : end+metaSize]
+		end += metaSize
+	}
+
+	h.payload = data[end:]
+	return h, nil
+}
+
+// unixNano converts t to Unix nanoseconds, mapping the zero time to 0.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano reverses unixNano.
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// JSONCodec encodes values with encoding/json.
+type JSONCodec struct{}
+
+// Marshal encodes v as JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// ContentType returns ContentTypeJSON.
+func (JSONCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+// GobCodec encodes values with encoding/gob.
+// Values are encoded as interface values so they can be decoded into an
+// interface{} later; concrete types must be registered with gob.Register.
+type GobCodec struct{}
+
+// Marshal encodes v as gob.
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v.
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	var decoded interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return err
+	}
+	return assign(v, decoded)
+}
+
+// ContentType returns ContentTypeGob.
+func (GobCodec) ContentType() string {
+	return ContentTypeGob
+}
+
+// MsgpackCodec encodes values with MessagePack, a compact binary format.
+type MsgpackCodec struct{}
+
+// Marshal encodes v as MessagePack.
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes MessagePack data into v.
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// ContentType returns ContentTypeMsgpack.
+func (MsgpackCodec) ContentType() string {
+	return ContentTypeMsgpack
+}
+
+// ProtobufCodec encodes proto.Message values.
+// New creates an empty message and is required to decode into an interface{},
+// since the wire format doesn't carry the message type.
+type ProtobufCodec struct {
+	New func() proto.Message
+}
+
+// Marshal encodes v, which must be a proto.Message.
+func (c ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: protobuf codec requires proto.Message, got %T", ErrInvalidValue, v)
+	}
+	return proto.Marshal(msg)
+}
+
+// Unmarshal decodes data into v, which must be a proto.Message or, when New
+// is set, a pointer to an interface{}.
+func (c ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+
+	if c.New == nil {
+		return fmt.Errorf("%w: protobuf codec requires proto.Message target, got %T", ErrInvalidValue, v)
+	}
+
+	msg := c.New()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return err
+	}
+	return assign(v, msg)
+}
+
+// ContentType returns ContentTypeProtobuf.
+func (c ProtobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+// assign stores value into the pointer target.
+func assign(target interface{}, value interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("%w: decode target must be a non-nil pointer, got %T", ErrInvalidValue, target)
+	}
+
+	if value == nil {
+		ptr.Elem().Set(reflect.Zero(ptr.Elem().Type()))
+		return nil
+	}
+
+	val := reflect.ValueOf(value)
+	if !val.Type().AssignableTo(ptr.Elem().Type()) {
+		return fmt.Errorf("%w: cannot assign %T to %s", ErrTypeMismatch, value, ptr.Elem().Type())
+	}
+
+	ptr.Elem().Set(val)
+	return nil
+}
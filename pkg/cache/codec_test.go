@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type codecTestValue struct {
+	ID    string
+	Count int
+}
+
+func init() {
+	gob.Register(codecTestValue{})
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		value interface{}
+	}{
+		{"json string", JSONCodec{}, "hello"},
+		{"gob struct", GobCodec{}, codecTestValue{ID: "a", Count: 3}},
+		{"msgpack string", MsgpackCodec{}, "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Encode(tt.codec, tt.value)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+
+			var decoded interface{}
+			if err := Decode(tt.codec, data, &decoded); err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+
+			if decoded != tt.value {
+				t.Errorf("Expected %v, got %v", tt.value, decoded)
+			}
+		})
+	}
+}
+
+func TestCodec_Protobuf(t *testing.T) {
+	codec := ProtobufCodec{New: func() proto.Message { return &wrapperspb.StringValue{} }}
+
+	data, err := Encode(codec, wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	// Decode into a concrete message
+	var msg wrapperspb.StringValue
+	if err := Decode(codec, data, &msg); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if msg.GetValue() != "hello" {
+		t.Errorf("Expected 'hello', got %q", msg.GetValue())
+	}
+
+	// Decode into interface{} using New
+	var decoded interface{}
+	if err := Decode(codec, data, &decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if sv, ok := decoded.(*wrapperspb.StringValue); !ok || sv.GetValue() != "hello" {
+		t.Errorf("Expected StringValue 'hello', got %v", decoded)
+	}
+
+	if _, err := Encode(codec, "not a message"); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue for non-message, got %v", err)
+	}
+}
+
+func TestDecode_CodecSwitch(t *testing.T) {
+	// Value written with msgpack stays readable by a reader configured for JSON
+	data, err := Encode(MsgpackCodec{}, "written-with-msgpack")
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var decoded interface{}
+	if err := Decode(JSONCodec{}, data, &decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded != "written-with-msgpack" {
+		t.Errorf("Expected 'written-with-msgpack', got %v", decoded)
+	}
+}
+
+func TestDecode_LegacyJSON(t *testing.T) {
+	var decoded interface{}
+	if err := Decode(MsgpackCodec{}, []byte(`{"id":"1"}`), &decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	m, ok := decoded.(map[string]interface{})
+	if !ok || m["id"] != "1" {
+		t.Errorf("Expected legacy JSON map, got %v", decoded)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"truncated header", []byte{headerMagic, headerVersion}, ErrInvalidValue},
+		{"unknown version", []byte{headerMagic, 99, 0}, ErrInvalidValue},
+		{"truncated content type", []byte{headerMagic, headerVersion, 10, 'a'}, ErrInvalidValue},
+		{"unknown codec", append([]byte{headerMagic, headerVersion, 0, 3}, "x/y"...), ErrUnknownCodec},
+		{"truncated entry metadata", append([]byte{headerMagic, headerVersionEntry, 0, 3}, "x/y"...), ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded interface{}
+			err := Decode(JSONCodec{}, tt.data, &decoded)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestEncodeEntry_RoundTrip(t *testing.T) {
+	now := time.Now()
+	entry := &CacheEntry{
+		Key:          "key",
+		Value:        "value",
+		ExpiresAt:    now.Add(time.Hour),
+		StaleAt:      now.Add(time.Minute),
+		CreatedAt:    now,
+		Version:      42,
+		LoadDuration: 150 * time.Millisecond,
+	}
+
+	data, _, err := EncodeEntry(JSONCodec{}, CompressionConfig{}, entry)
+	if err != nil {
+		t.Fatalf("EncodeEntry failed: %v", err)
+	}
+
+	var decoded CacheEntry
+	hasMeta, err := DecodeEntry(JSONCodec{}, data, &decoded)
+	if err != nil {
+		t.Fatalf("DecodeEntry failed: %v", err)
+	}
+	if !hasMeta {
+		t.Error("Expected metadata to be present")
+	}
+	if decoded.Value != "value" {
+		t.Errorf("Expected 'value', got %v", decoded.Value)
+	}
+	if !decoded.ExpiresAt.Equal(entry.ExpiresAt) || !decoded.StaleAt.Equal(entry.StaleAt) ||
+		!decoded.CreatedAt.Equal(entry.CreatedAt) || decoded.Version != 42 ||
+		decoded.LoadDuration != 150*time.Millisecond {
+		t.Errorf("Metadata mismatch: got %+v", decoded)
+	}
+
+	// Plain Decode skips the metadata
+	var value interface{}
+	if err := Decode(JSONCodec{}, data, &value); err != nil || value != "value" {
+		t.Errorf("Expected Decode to read entry values, got %v (err: %v)", value, err)
+	}
+
+	// Version 3 headers have no load duration
+	metaEnd := 4 + len(ContentTypeJSON) + entryMetaSize
+	v3 := append([]byte{}, data[:metaEnd-8]...)
+	v3 = append(v3, data[metaEnd:]...)
+	v3[1] = 3
+
+	var old CacheEntry
+	if _, err := DecodeEntry(JSONCodec{}, v3, &old); err != nil {
+		t.Fatalf("DecodeEntry v3 failed: %v", err)
+	}
+	if old.Value != "value" || old.Version != 42 || old.LoadDuration != 0 {
+		t.Errorf("Expected v3 entry without load duration, got %+v", old)
+	}
+}
+
+func TestDecodeEntry_WithoutMetadata(t *testing.T) {
+	data, err := Encode(JSONCodec{}, "value")
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var decoded CacheEntry
+	hasMeta, err := DecodeEntry(JSONCodec{}, data, &decoded)
+	if err != nil {
+		t.Fatalf("DecodeEntry failed: %v", err)
+	}
+	if hasMeta {
+		t.Error("Expected no metadata")
+	}
+	if decoded.Value != "value" || !decoded.StaleAt.IsZero() || !decoded.ExpiresAt.IsZero() {
+		t.Errorf("Expected only Value to be set, got %+v", decoded)
+	}
+	if decoded.IsStale() {
+		t.Error("Entry without StaleAt must never be stale")
+	}
+}
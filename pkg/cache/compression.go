@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression identifies the algorithm used to compress a stored value.
+// It is written into the value header, so compressed and uncompressed
+// values can coexist under the same configuration.
+type Compression byte
+
+const (
+	// CompressionNone stores values as-is
+	CompressionNone Compression = iota
+	// CompressionGzip uses gzip (best ratio, slowest)
+	CompressionGzip
+	// CompressionZstd uses zstd (good ratio, fast)
+	CompressionZstd
+	// CompressionSnappy uses snappy (lower ratio, fastest)
+	CompressionSnappy
+)
+
+// String returns the name of the compression algorithm.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	case CompressionSnappy:
+		return "snappy"
+	default:
+		return "unknown"
+	}
+}
+
+// CompressionConfig configures value compression for serialized layers.
+type CompressionConfig struct {
+	// Algorithm is the compression algorithm (default: CompressionNone)
+	Algorithm Compression
+
+	// MinSize is the encoded size in bytes below which values are stored
+	// uncompressed, since small values rarely shrink (default: 1024)
+	MinSize int
+}
+
+// shouldCompress reports whether a payload of the given size should be compressed.
+func (c CompressionConfig) shouldCompress(size int) bool {
+	if c.Algorithm == CompressionNone {
+		return false
+	}
+	minSize := c.MinSize
+	if minSize <= 0 {
+		minSize = 1024
+	}
+	return size >= minSize
+}
+
+// zstd encoders and decoders are expensive to create but safe for concurrent
+// use through EncodeAll/DecodeAll, so a single instance of each is shared.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func initZstd() error {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdErr
+}
+
+// Compress compresses data with the given algorithm.
+func Compress(algorithm Compression, data []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, fmt.Errorf("gzip compress: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("gzip compress: %w", err)
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		if err := initZstd(); err != nil {
+			return nil, fmt.Errorf("zstd compress: %w", err)
+		}
+		return zstdEncoder.EncodeAll(data, nil), nil
+	case CompressionSnappy:
+		return snappy.Encode(nil, data), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %d", ErrInvalidValue, algorithm)
+	}
+}
+
+// Decompress reverses Compress.
+func Decompress(algorithm Compression, data []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip decompress: %w", err)
+		}
+		defer zr.Close()
+		out, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("gzip decompress: %w", err)
+		}
+		return out, nil
+	case CompressionZstd:
+		if err := initZstd(); err != nil {
+			return nil, fmt.Errorf("zstd decompress: %w", err)
+		}
+		out, err := zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd decompress: %w", err)
+		}
+		return out, nil
+	case CompressionSnappy:
+		out, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("snappy decompress: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %d", ErrInvalidValue, algorithm)
+	}
+}
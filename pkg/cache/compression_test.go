@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompression_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"id":"tx","amount":10.5},`), 200)
+
+	for _, alg := range []Compression{CompressionNone, CompressionGzip, CompressionZstd, CompressionSnappy} {
+		t.Run(alg.String(), func(t *testing.T) {
+			compressed, err := Compress(alg, data)
+			if err != nil {
+				t.Fatalf("Compress failed: %v", err)
+			}
+
+			if alg != CompressionNone && len(compressed) >= len(data) {
+				t.Errorf("Expected compressed size < %d, got %d", len(data), len(compressed))
+			}
+
+			decompressed, err := Decompress(alg, compressed)
+			if err != nil {
+				t.Fatalf("Decompress failed: %v", err)
+			}
+			if !bytes.Equal(decompressed, data) {
+				t.Error("Decompressed data doesn't match original")
+			}
+		})
+	}
+}
+
+func TestCompression_Unknown(t *testing.T) {
+	if _, err := Compress(Compression(99), []byte("x")); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+	if _, err := Decompress(Compression(99), []byte("x")); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+}
+
+func TestEncodeCompressed_Threshold(t *testing.T) {
+	config := CompressionConfig{Algorithm: CompressionZstd, MinSize: 512}
+
+	tests := []struct {
+		name           string
+		value          string
+		wantCompressed bool
+	}{
+		{"below threshold", "small", false},
+		{"above threshold", strings.Repeat("abcdefgh", 200), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, stats, err := EncodeCompressed(JSONCodec{}, config, tt.value)
+			if err != nil {
+				t.Fatalf("EncodeCompressed failed: %v", err)
+			}
+
+			compressed := stats.Compression != CompressionNone
+			if compressed != tt.wantCompressed {
+				t.Errorf("Expected compressed=%v, got %v (stats %+v)", tt.wantCompressed, compressed, stats)
+			}
+			if compressed && stats.StoredSize >= stats.RawSize {
+				t.Errorf("Expected stored size < raw size, got %+v", stats)
+			}
+
+			var decoded interface{}
+			if err := Decode(JSONCodec{}, data, &decoded); err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if decoded != tt.value {
+				t.Error("Decoded value doesn't match original")
+			}
+		})
+	}
+}
+
+func TestDecode_HeaderVersion1(t *testing.T) {
+	// Values written before the compression flag existed
+	data := append([]byte{headerMagic, 1, byte(len(ContentTypeJSON))}, ContentTypeJSON...)
+	data = append(data, `"v1"`...)
+
+	var decoded interface{}
+	if err := Decode(JSONCodec{}, data, &decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded != "v1" {
+		t.Errorf("Expected 'v1', got %v", decoded)
+	}
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"errors"
 	"fmt"
+	"sort"
 )
 
 // Common cache operation errors.
@@ -28,6 +29,15 @@ var (
 
 	// ErrCircuitOpen is returned when the circuit breaker is in open state
 	ErrCircuitOpen = errors.New("cache: circuit breaker open")
+
+	// ErrTypeMismatch is returned when a cached value cannot be converted to the requested type
+	ErrTypeMismatch = errors.New("cache: type mismatch")
+
+	// ErrUnknownCodec is returned when stored bytes were written by a codec that isn't registered
+	ErrUnknownCodec = errors.New("cache: unknown codec")
+
+	// ErrNotSupported is returned when a layer doesn't support an operation
+	ErrNotSupported = errors.New("cache: operation not supported")
 )
 
 // IsNotFound checks if the given error indicates that a key was not found.
@@ -68,6 +78,10 @@ func ClassifyError(err error) string {
 		return "invalid_key"
 	case errors.Is(err, ErrInvalidValue):
 		return "invalid_value"
+	case errors.Is(err, ErrTypeMismatch):
+		return "type_mismatch"
+	case errors.Is(err, ErrNotSupported):
+		return "not_supported"
 	default:
 		// Check for common error patterns in the error message
 		errStr := err.Error()
@@ -127,6 +141,12 @@ func IsCircuitOpen(err error) bool {
 	return errors.Is(err, ErrCircuitOpen)
 }
 
+// IsTypeMismatch checks if the given error indicates a cached value had an unexpected type.
+// This is a convenience function for checking typed access errors.
+func IsTypeMismatch(err error) bool {
+	return errors.Is(err, ErrTypeMismatch)
+}
+
 // WrapError wraps an error with additional context about the cache operation.
 // This is useful for adding layer-specific information to errors.
 func WrapError(err error, layer string, operation string) error {
@@ -135,3 +155,64 @@ func WrapError(err error, layer string, operation string) error {
 	}
 	return fmt.Errorf("cache layer %s %s: %w", layer, operation, err)
 }
+
+// BatchError reports the keys that failed in a batch operation, so callers can
+// tell backend failures from misses (missing keys are never errors). Batch
+// operations return it along with the results of the keys that succeeded.
+type BatchError struct {
+	// Errors maps each failed key to its error
+	Errors map[string]error
+}
+
+// Add records the failure of a key.
+func (e *BatchError) Add(key string, err error) {
+	if e.Errors == nil {
+		e.Errors = make(map[string]error)
+	}
+	e.Errors[key] = err
+}
+
+// Err returns e if any key failed, nil otherwise.
+func (e *BatchError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Keys returns the failed keys in sorted order.
+func (e *BatchError) Keys() []string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Error summarizes the failures, naming the first failed key.
+func (e *BatchError) Error() string {
+	keys := e.Keys()
+	if len(keys) == 0 {
+		return "cache: batch failed"
+	}
+	return fmt.Sprintf("cache: batch failed for %d keys (%s: %v)", len(keys), keys[0], e.Errors[keys[0]])
+}
+
+// Unwrap returns the per-key errors, so errors.Is matches any of them.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, key := range e.Keys() {
+		errs = append(errs, e.Errors[key])
+	}
+	return errs
+}
+
+// AsBatchError extracts a BatchError from err.
+func AsBatchError(err error) (*BatchError, bool) {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr, true
+	}
+	return nil, false
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -165,3 +166,39 @@ func TestErrorVariables(t *testing.T) {
 		}
 	}
 }
+
+func TestBatchError(t *testing.T) {
+	var batchErr BatchError
+	if batchErr.Err() != nil {
+		t.Error("Expected nil error without failures")
+	}
+
+	batchErr.Add("b", ErrTimeout)
+	batchErr.Add("a", ErrLayerUnavailable)
+
+	err := batchErr.Err()
+	if err == nil {
+		t.Fatal("Expected error with failures")
+	}
+
+	got, ok := AsBatchError(fmt.Errorf("wrapped: %w", err))
+	if !ok {
+		t.Fatal("Expected AsBatchError to find the batch error")
+	}
+	if keys := got.Keys(); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("Expected failed keys [a b], got %v", keys)
+	}
+
+	// errors.Is matches any key's error, but a batch error is never a miss
+	if !IsTimeout(err) || !IsUnavailable(err) {
+		t.Error("Expected per-key errors to be matched by errors.Is")
+	}
+	if IsNotFound(err) {
+		t.Error("Expected batch error not to be a miss")
+	}
+
+	expected := "cache: batch failed for 2 keys (a: cache: layer unavailable)"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
@@ -96,6 +96,13 @@ func (kp *KeyPattern) Build(parts ...string) string {
 	return result
 }
 
+// Prefix returns the start shared by every key built with parts, for use
+// with prefix invalidation.
+// Example: pattern.Prefix() -> "user:"
+func (kp *KeyPattern) Prefix() string {
+	return kp.prefix + kp.separator
+}
+
 // MustBuild is like Build but panics if the resulting key is invalid.
 // Use with caution - prefer Build with validation.
 func (kp *KeyPattern) MustBuild(parts ...string) string {
@@ -92,6 +92,22 @@ func TestKeyPattern_Build(t *testing.T) {
 	}
 }
 
+func TestKeyPattern_Prefix(t *testing.T) {
+	pattern := NewKeyPattern("user", ":")
+
+	prefix := pattern.Prefix()
+	if prefix != "user:" {
+		t.Errorf("Prefix() = %q, want %q", prefix, "user:")
+	}
+	if key := pattern.Build("123"); !strings.HasPrefix(key, prefix) {
+		t.Errorf("Build(\"123\") = %q, want prefix %q", key, prefix)
+	}
+	// Keys of a pattern sharing the name are not matched
+	if key := NewKeyPattern("users", ":").Build("1"); strings.HasPrefix(key, prefix) {
+		t.Errorf("Build(\"1\") = %q should not match prefix %q", key, prefix)
+	}
+}
+
 func TestKeyPattern_MustBuild(t *testing.T) {
 	pattern := NewKeyPattern("user", ":")
 
@@ -29,6 +29,58 @@ type CacheLayer interface {
 	Close() error
 }
 
+// EntryLayer is implemented by layers that can store metadata alongside values.
+// The chain uses it for stale-while-revalidate; layers that don't implement it
+// only hold plain values.
+type EntryLayer interface {
+	CacheLayer
+
+	// GetEntry retrieves a value with its metadata.
+	// Fields the layer doesn't know (e.g. for values stored with Set) are zero.
+	// Returns ErrKeyNotFound if the key doesn't exist or is past its ExpiresAt.
+	GetEntry(ctx context.Context, key string) (*CacheEntry, error)
+
+	// SetEntry stores a value with its metadata.
+	// The entry is removed from the layer at ExpiresAt.
+	SetEntry(ctx context.Context, entry *CacheEntry) error
+}
+
+// TaggedLayer is implemented by layers that index keys by tag, so groups of
+// related entries (e.g. everything cached for one account) can be removed
+// without knowing their keys.
+type TaggedLayer interface {
+	CacheLayer
+
+	// SetWithTags stores a value like Set and attaches tags to it.
+	SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags []string) error
+
+	// InvalidateTag removes every entry carrying tag and returns their keys.
+	// Layers may return keys that were already gone.
+	InvalidateTag(ctx context.Context, tag string) ([]string, error)
+}
+
+// PrefixLayer is implemented by layers that can remove every key starting
+// with a prefix, e.g. all keys built from one KeyPattern.
+type PrefixLayer interface {
+	CacheLayer
+
+	// DeletePrefix removes the keys starting with prefix and returns how many
+	// were removed. Keys are removed in batches; onBatch, if not nil, is called
+	// with the keys of each batch once it is removed.
+	DeletePrefix(ctx context.Context, prefix string, onBatch func(keys []string)) (int, error)
+}
+
+// FencedLayer is implemented by shared layers that also store load leases,
+// so a value loaded under a lease can be written only while the lease is
+// still held, with the check and the write in one atomic step.
+type FencedLayer interface {
+	CacheLayer
+
+	// SetEntryFenced stores entry like SetEntry if the lease stored at
+	// fenceKey still holds token, and reports whether it was stored.
+	SetEntryFenced(ctx context.Context, entry *CacheEntry, fenceKey string, token uint64) (bool, error)
+}
+
 // CacheEntry represents a cached value with metadata.
 // It includes the key, value, expiration time, version for optimistic concurrency,
 // and creation timestamp for debugging and metrics.
@@ -39,14 +91,24 @@ type CacheEntry struct {
 	// Value is the cached value (can be any type)
 	Value interface{}
 
-	// ExpiresAt is when this entry should be considered expired
+	// ExpiresAt is when this entry should be considered expired (hard TTL).
+	// Zero means unknown.
 	ExpiresAt time.Time
 
+	// StaleAt is when this entry should be refreshed (soft TTL).
+	// Between StaleAt and ExpiresAt the value may still be served while a
+	// refresh runs in the background. Zero means the entry never goes stale.
+	StaleAt time.Time
+
 	// Version is used for optimistic concurrency control and cache invalidation
 	Version int64
 
 	// CreatedAt is when this entry was first created
 	CreatedAt time.Time
+
+	// LoadDuration is how long the origin took to produce the value (0 if unknown).
+	// Used to schedule probabilistic early refreshes.
+	LoadDuration time.Duration
 }
 
 // IsExpired checks if the cache entry has expired based on the current time.
@@ -54,6 +116,11 @@ func (e *CacheEntry) IsExpired() bool {
 	return time.Now().After(e.ExpiresAt)
 }
 
+// IsStale checks if the entry is past its soft TTL.
+func (e *CacheEntry) IsStale() bool {
+	return !e.StaleAt.IsZero() && time.Now().After(e.StaleAt)
+}
+
 // TimeToLive returns the remaining time-to-live for this entry.
 // Returns 0 if already expired.
 func (e *CacheEntry) TimeToLive() time.Duration {
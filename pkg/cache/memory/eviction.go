@@ -0,0 +1,394 @@
+package memory
+
+import (
+	"container/list"
+	"hash/maphash"
+)
+
+// EvictionPolicy selects which entry is evicted when the cache is full.
+type EvictionPolicy int
+
+const (
+	// EvictionLRU evicts the least recently used entry (default).
+	EvictionLRU EvictionPolicy = iota
+
+	// EvictionLFU evicts the least frequently used entry,
+	// breaking ties by least recent use.
+	EvictionLFU
+
+	// EvictionTinyLFU uses W-TinyLFU: a small LRU admission window in front of
+	// a segmented LRU main area, where new entries only displace main entries
+	// that have been requested less often. Resistant to scans and one-hit wonders.
+	EvictionTinyLFU
+)
+
+// String returns the name of the eviction policy.
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictionLRU:
+		return "lru"
+	case EvictionLFU:
+		return "lfu"
+	case EvictionTinyLFU:
+		return "tinylfu"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictionReason describes why an entry left the cache.
+type EvictionReason int
+
+const (
+	// EvictionReasonCapacity means the entry was evicted to make room
+	EvictionReasonCapacity EvictionReason = iota
+
+	// EvictionReasonExpired means the entry's TTL elapsed
+	EvictionReasonExpired
+)
+
+// String returns the name of the eviction reason.
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionReasonCapacity:
+		return "capacity"
+	case EvictionReasonExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// evictionPolicy tracks entries and picks eviction victims in constant time.
+// All methods are called with the cache lock held.
+type evictionPolicy interface {
+	// add registers a newly inserted entry
+	add(e *entry)
+
+	// access records a read or overwrite of an existing entry
+	access(e *entry)
+
+	// remove forgets an entry that left the cache for any reason
+	remove(e *entry)
+
+	// victim returns the entry to evict to make room for a new one, or nil
+	// if there are none. The caller removes the returned entry.
+	victim() *entry
+}
+
+// newEvictionPolicy creates the policy implementation for the given capacity.
+func newEvictionPolicy(policy EvictionPolicy, capacity int) evictionPolicy {
+	switch policy {
+	case EvictionLFU:
+		return newLFUPolicy()
+	case EvictionTinyLFU:
+		return newTinyLFUPolicy(capacity)
+	default:
+		return newLRUPolicy()
+	}
+}
+
+// lruPolicy keeps entries in a recency list, most recent at the front.
+type lruPolicy struct {
+	ll *list.List
+}
+
+func newLRUPolicy() *lruPolicy {
+	return &lruPolicy{ll: list.New()}
+}
+
+func (p *lruPolicy) add(e *entry) {
+	e.elem = p.ll.PushFront(e)
+}
+
+func (p *lruPolicy) access(e *entry) {
+	p.ll.MoveToFront(e.elem)
+}
+
+func (p *lruPolicy) remove(e *entry) {
+	p.ll.Remove(e.elem)
+	e.elem = nil
+}
+
+func (p *lruPolicy) victim() *entry {
+	if back := p.ll.Back(); back != nil {
+		return back.Value.(*entry)
+	}
+	return nil
+}
+
+// lfuPolicy is the constant-time LFU scheme: an ascending list of frequency
+// nodes, each holding the entries with that use count in recency order.
+type lfuPolicy struct {
+	freqs *list.List // of *freqNode, lowest frequency at the front
+}
+
+type freqNode struct {
+	freq  int
+	items *list.List // of *entry, most recent at the front
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{freqs: list.New()}
+}
+
+func (p *lfuPolicy) add(e *entry) {
+	front := p.freqs.Front()
+	if front == nil || front.Value.(*freqNode).freq != 1 {
+		front = p.freqs.PushFront(&freqNode{freq: 1, items: list.New()})
+	}
+	e.freqElem = front
+	e.elem = front.Value.(*freqNode).items.PushFront(e)
+}
+
+func (p *lfuPolicy) access(e *entry) {
+	cur := e.freqElem
+	node := cur.Value.(*freqNode)
+
+	next := cur.Next()
+	if next == nil || next.Value.(*freqNode).freq != node.freq+1 {
+		next = p.freqs.InsertAfter(&freqNode{freq: node.freq + 1, items: list.New()}, cur)
+	}
+
+	node.items.Remove(e.elem)
+	if node.items.Len() == 0 {
+		p.freqs.Remove(cur)
+	}
+
+	e.freqElem = next
+	e.elem = next.Value.(*freqNode).items.PushFront(e)
+}
+
+func (p *lfuPolicy) remove(e *entry) {
+	node := e.freqElem.Value.(*freqNode)
+	node.items.Remove(e.elem)
+	if node.items.Len() == 0 {
+		p.freqs.Remove(e.freqElem)
+	}
+	e.elem = nil
+	e.freqElem = nil
+}
+
+func (p *lfuPolicy) victim() *entry {
+	front := p.freqs.Front()
+	if front == nil {
+		return nil
+	}
+	if back := front.Value.(*freqNode).items.Back(); back != nil {
+		return back.Value.(*entry)
+	}
+	return nil
+}
+
+// Segments of the W-TinyLFU policy.
+const (
+	segmentWindow = iota
+	segmentProbation
+	segmentProtected
+)
+
+// tinyLFUPolicy implements W-TinyLFU. New entries land in an LRU window
+// (~1% of capacity). Entries leaving the window compete with the probation
+// tail of the main segmented LRU, and the one with the higher estimated
+// access frequency stays. Entries hit while in probation are promoted to
+// the protected segment (~80% of the main area).
+type tinyLFUPolicy struct {
+	window    *list.List
+	probation *list.List
+	protected *list.List
+
+	capacity     int
+	windowCap    int
+	protectedCap int
+
+	sketch *countMinSketch
+}
+
+func newTinyLFUPolicy(capacity int) *tinyLFUPolicy {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+
+	windowCap := capacity / 100
+	if windowCap < 1 {
+		windowCap = 1
+	}
+	mainCap := capacity - windowCap
+	protectedCap := mainCap * 8 / 10
+
+	return &tinyLFUPolicy{
+		window:       list.New(),
+		probation:    list.New(),
+		protected:    list.New(),
+		capacity:     capacity,
+		windowCap:    windowCap,
+		protectedCap: protectedCap,
+		sketch:       newCountMinSketch(capacity),
+	}
+}
+
+func (p *tinyLFUPolicy) size() int {
+	return p.window.Len() + p.probation.Len() + p.protected.Len()
+}
+
+func (p *tinyLFUPolicy) add(e *entry) {
+	p.sketch.increment(e.key)
+	e.segment = segmentWindow
+	e.elem = p.window.PushFront(e)
+
+	// While the cache is filling up, overflow from the window moves
+	// straight to probation without an admission contest
+	for p.window.Len() > p.windowCap {
+		p.moveToProbation(p.window.Back().Value.(*entry))
+	}
+}
+
+func (p *tinyLFUPolicy) access(e *entry) {
+	p.sketch.increment(e.key)
+
+	switch e.segment {
+	case segmentWindow:
+		p.window.MoveToFront(e.elem)
+	case segmentProbation:
+		p.probation.Remove(e.elem)
+		e.segment = segmentProtected
+		e.elem = p.protected.PushFront(e)
+
+		// Demote the coldest protected entry when the segment overflows
+		if p.protected.Len() > p.protectedCap {
+			demoted := p.protected.Back().Value.(*entry)
+			p.protected.Remove(demoted.elem)
+			demoted.segment = segmentProbation
+			demoted.elem = p.probation.PushFront(demoted)
+		}
+	case segmentProtected:
+		p.protected.MoveToFront(e.elem)
+	}
+}
+
+func (p *tinyLFUPolicy) remove(e *entry) {
+	switch e.segment {
+	case segmentWindow:
+		p.window.Remove(e.elem)
+	case segmentProbation:
+		p.probation.Remove(e.elem)
+	case segmentProtected:
+		p.protected.Remove(e.elem)
+	}
+	e.elem = nil
+}
+
+func (p *tinyLFUPolicy) victim() *entry {
+	mainVictim := p.mainVictim()
+
+	// Window has room for the incoming entry: evict from the main area
+	if p.window.Len() < p.windowCap && mainVictim != nil {
+		return mainVictim
+	}
+
+	back := p.window.Back()
+	if back == nil {
+		return mainVictim
+	}
+	candidate := back.Value.(*entry)
+	if mainVictim == nil {
+		return candidate
+	}
+
+	// Window is full: its oldest entry either enters the main area, if it's
+	// been requested more often than the entry it would displace, or is evicted
+	if p.sketch.estimate(candidate.key) > p.sketch.estimate(mainVictim.key) {
+		p.moveToProbation(candidate)
+		return mainVictim
+	}
+	return candidate
+}
+
+// mainVictim returns the coldest entry of the main area.
+func (p *tinyLFUPolicy) mainVictim() *entry {
+	if back := p.probation.Back(); back != nil {
+		return back.Value.(*entry)
+	}
+	if back := p.protected.Back(); back != nil {
+		return back.Value.(*entry)
+	}
+	return nil
+}
+
+func (p *tinyLFUPolicy) moveToProbation(e *entry) {
+	p.window.Remove(e.elem)
+	e.segment = segmentProbation
+	e.elem = p.probation.PushFront(e)
+}
+
+// countMinSketch estimates access frequencies with 4-bit saturating counters.
+// Counters are halved periodically so the history ages and adapts to
+// changing workloads.
+type countMinSketch struct {
+	rows       [4][]uint8
+	mask       uint64
+	seed       maphash.Seed
+	additions  int
+	resetAfter int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 64
+	for width < capacity {
+		width <<= 1
+	}
+
+	s := &countMinSketch{
+		mask:       uint64(width - 1),
+		seed:       maphash.MakeSeed(),
+		resetAfter: 10 * capacity,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexes derives one counter index per row from a single hash.
+func (s *countMinSketch) indexes(key string) [4]uint64 {
+	h := maphash.String(s.seed, key)
+	h2 := h>>32 | h<<32
+	var idx [4]uint64
+	for i := range idx {
+		idx[i] = (h + uint64(i)*h2) & s.mask
+	}
+	return idx
+}
+
+func (s *countMinSketch) increment(key string) {
+	for i, idx := range s.indexes(key) {
+		if s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAfter {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	min := uint8(15)
+	for i, idx := range s.indexes(key) {
+		if v := s.rows[i][idx]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// reset halves all counters to age the frequency history.
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_LFU(t *testing.T) {
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:           "test",
+		MaxSize:        2,
+		EvictionPolicy: EvictionLFU,
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	cache.Set(ctx, "hot", "v", 0)
+	cache.Set(ctx, "cold", "v", 0)
+
+	// Make "hot" more frequently used; "cold" is accessed most recently
+	for i := 0; i < 3; i++ {
+		cache.Get(ctx, "hot")
+	}
+	cache.Get(ctx, "cold")
+
+	cache.Set(ctx, "new", "v", 0)
+
+	if _, err := cache.Get(ctx, "hot"); err != nil {
+		t.Error("Frequently used key should not be evicted")
+	}
+	if _, err := cache.Get(ctx, "cold"); err == nil {
+		t.Error("Least frequently used key should have been evicted")
+	}
+}
+
+func TestMemoryCache_TinyLFU_ScanResistance(t *testing.T) {
+	const size = 100
+
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:           "test",
+		MaxSize:        size,
+		EvictionPolicy: EvictionTinyLFU,
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	// Build a frequently accessed working set
+	for i := 0; i < size/2; i++ {
+		cache.Set(ctx, "hot"+strconv.Itoa(i), i, 0)
+	}
+	for round := 0; round < 5; round++ {
+		for i := 0; i < size/2; i++ {
+			cache.Get(ctx, "hot"+strconv.Itoa(i))
+		}
+	}
+
+	// A one-off scan much larger than the cache
+	for i := 0; i < size*10; i++ {
+		cache.Set(ctx, "scan"+strconv.Itoa(i), i, 0)
+	}
+
+	kept := 0
+	for i := 0; i < size/2; i++ {
+		if _, err := cache.Get(ctx, "hot"+strconv.Itoa(i)); err == nil {
+			kept++
+		}
+	}
+	if kept < size/2*9/10 {
+		t.Errorf("Expected most of the hot set to survive the scan, kept %d/%d", kept, size/2)
+	}
+
+	if stats := cache.Stats(); stats.Size > size {
+		t.Errorf("Size %d exceeds MaxSize %d", stats.Size, size)
+	}
+}
+
+func TestMemoryCache_EvictionCallbackAndStats(t *testing.T) {
+	var mu sync.Mutex
+	reasons := make(map[string]EvictionReason)
+
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		MaxSize:         1,
+		CleanupInterval: time.Hour,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			mu.Lock()
+			reasons[key] = reason
+			mu.Unlock()
+		},
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "v1", 0)
+	cache.Set(ctx, "key2", "v2", 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+	cache.Get(ctx, "key2")
+
+	mu.Lock()
+	if reasons["key1"] != EvictionReasonCapacity {
+		t.Errorf("Expected key1 evicted for capacity, got %v", reasons["key1"])
+	}
+	if r, ok := reasons["key2"]; !ok || r != EvictionReasonExpired {
+		t.Errorf("Expected key2 removed on expiry, got %v (reported=%v)", r, ok)
+	}
+	mu.Unlock()
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 {
+		t.Errorf("Expected 1 eviction, got %d", stats.Evictions)
+	}
+	if stats.Expirations != 1 {
+		t.Errorf("Expected 1 expiration, got %d", stats.Expirations)
+	}
+}
+
+func TestMemoryCache_OverwriteDoesNotEvict(t *testing.T) {
+	for _, policy := range []EvictionPolicy{EvictionLRU, EvictionLFU, EvictionTinyLFU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			cache := NewMemoryCache(MemoryCacheConfig{
+				Name:           "test",
+				MaxSize:        2,
+				EvictionPolicy: policy,
+			})
+			defer cache.Close()
+
+			ctx := context.Background()
+			cache.Set(ctx, "key1", "v1", 0)
+			cache.Set(ctx, "key2", "v2", 0)
+			cache.Set(ctx, "key2", "v2-updated", 0)
+
+			if _, err := cache.Get(ctx, "key1"); err != nil {
+				t.Error("Overwriting an existing key should not evict others")
+			}
+			if value, _ := cache.Get(ctx, "key2"); value != "v2-updated" {
+				t.Errorf("Expected 'v2-updated', got %v", value)
+			}
+			if stats := cache.Stats(); stats.Evictions != 0 {
+				t.Errorf("Expected 0 evictions, got %d", stats.Evictions)
+			}
+		})
+	}
+}
+
+func BenchmarkMemoryCache_SetEvict(b *testing.B) {
+	for _, policy := range []EvictionPolicy{EvictionLRU, EvictionLFU, EvictionTinyLFU} {
+		b.Run(policy.String(), func(b *testing.B) {
+			cache := NewMemoryCache(MemoryCacheConfig{
+				Name:           "bench",
+				MaxSize:        100000,
+				EvictionPolicy: policy,
+			})
+			defer cache.Close()
+
+			ctx := context.Background()
+			for i := 0; i < 100000; i++ {
+				cache.Set(ctx, "key"+strconv.Itoa(i), i, 0)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cache.Set(ctx, "new"+strconv.Itoa(i), i, 0)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package memory
 
 import (
+	"container/list"
 	"context"
+	"fmt"
+	"hash/maphash"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -13,13 +17,16 @@ import (
 )
 
 // MemoryCache is an in-memory cache implementation that satisfies the CacheLayer interface.
-// It provides thread-safe operations, automatic TTL expiration, and optional LRU eviction.
+// It provides thread-safe operations, automatic TTL expiration, and constant-time
+// eviction (LRU, LFU or W-TinyLFU) when MaxSize is reached.
+// Keys can be hash-partitioned across independently locked shards to reduce
+// lock contention under parallel load.
 type MemoryCache struct {
-	// data stores the cache entries
-	data map[string]*entry
+	// shards hold the cache entries, each with its own lock and eviction policy
+	shards []*shard
 
-	// mu protects concurrent access to data
-	mu sync.RWMutex
+	// seed for hashing keys to shards
+	seed maphash.Seed
 
 	// config holds the cache configuration
 	config MemoryCacheConfig
@@ -35,15 +42,28 @@ type MemoryCache struct {
 
 	// logger for structured logging
 	logger *logging.Logger
+
+	// Statistics (accessed atomically)
+	evictions   int64
+	expirations int64
 }
 
-// entry represents a cache entry with metadata for LRU and TTL
+// entry represents a cache entry with metadata for eviction and TTL
 type entry struct {
-	key        string
-	value      interface{}
-	expiresAt  time.Time
-	accessedAt time.Time
-	version    int64
+	key       string
+	value     interface{}
+	size      int64 // estimated bytes, 0 when sizes aren't tracked
+	expiresAt time.Time
+	staleAt   time.Time // soft expiry, zero if never stale
+	createdAt time.Time
+	version   int64
+	loadTime  time.Duration // origin load duration, 0 if unknown
+	tags      []string      // tags for group invalidation, see SetWithTags
+
+	// Eviction policy bookkeeping
+	elem     *list.Element // position in the policy's recency list
+	freqElem *list.Element // frequency node (LFU only)
+	segment  int           // window/probation/protected (W-TinyLFU only)
 }
 
 // MemoryCacheConfig holds configuration for the memory cache
@@ -54,6 +74,34 @@ type MemoryCacheConfig struct {
 	// MaxSize is the maximum number of entries (0 = unlimited)
 	MaxSize int
 
+	// MaxBytes is the maximum estimated memory used by entries (0 = unlimited).
+	// When set, entries are evicted until the new value fits, and values
+	// larger than a shard's share of the budget are rejected.
+	MaxBytes int64
+
+	// Sizer estimates value sizes for MaxBytes and the Bytes statistic
+	// (default: DefaultSizer when MaxBytes is set, otherwise sizes aren't tracked)
+	Sizer Sizer
+
+	// Shards is the number of independently locked partitions (default: 1).
+	// MaxSize is split evenly across shards, and eviction happens per shard,
+	// so with more than one shard the evicted entry is only the best victim
+	// within its shard. Capped at MaxSize when MaxSize is set. Reads share
+	// a shard's lock; only writes take it exclusively.
+	Shards int
+
+	// PrefixIndex keeps keys in a radix tree so DeletePrefix visits only the
+	// matching keys instead of scanning every entry, at the cost of extra
+	// work on each insert and removal (default: false)
+	PrefixIndex bool
+
+	// EvictionPolicy selects the entry evicted when MaxSize is reached (default: EvictionLRU)
+	EvictionPolicy EvictionPolicy
+
+	// OnEvict is called after an entry is evicted for capacity or removed on expiry (optional).
+	// It runs outside the cache lock, so it may safely call back into the cache.
+	OnEvict func(key string, value interface{}, reason EvictionReason)
+
 	// DefaultTTL is the default time-to-live for entries
 	DefaultTTL time.Duration
 
@@ -77,6 +125,15 @@ func NewMemoryCache(config MemoryCacheConfig) *MemoryCache {
 	if config.CleanupInterval == 0 {
 		config.CleanupInterval = time.Minute
 	}
+	if config.MaxBytes > 0 && config.Sizer == nil {
+		config.Sizer = DefaultSizer
+	}
+	if config.Shards <= 0 {
+		config.Shards = 1
+	}
+	if config.MaxSize > 0 && config.Shards > config.MaxSize {
+		config.Shards = config.MaxSize
+	}
 
 	// Set logger
 	logger := config.Logger
@@ -85,16 +142,40 @@ func NewMemoryCache(config MemoryCacheConfig) *MemoryCache {
 	}
 
 	cache := &MemoryCache{
-		data:          make(map[string]*entry),
+		shards:        make([]*shard, config.Shards),
+		seed:          maphash.MakeSeed(),
 		config:        config,
 		stopCleanup:   make(chan struct{}),
 		cleanupTicker: time.NewTicker(config.CleanupInterval),
 		logger:        logger.Named(config.Name),
 	}
 
+	// Split MaxSize and MaxBytes across shards, spreading the remainder over the first ones
+	shards := int64(config.Shards)
+	for i := range cache.shards {
+		maxSize := 0
+		if config.MaxSize > 0 {
+			maxSize = config.MaxSize / config.Shards
+			if i < config.MaxSize%config.Shards {
+				maxSize++
+			}
+		}
+		var maxBytes int64
+		if config.MaxBytes > 0 {
+			maxBytes = config.MaxBytes / shards
+			if int64(i) < config.MaxBytes%shards {
+				maxBytes++
+			}
+		}
+		cache.shards[i] = newShard(config.EvictionPolicy, maxSize, maxBytes, config.PrefixIndex)
+	}
+
 	cache.logger.Info("memory cache initialized",
 		zap.String("name", config.Name),
 		zap.Int("max_size", config.MaxSize),
+		zap.Int64("max_bytes", config.MaxBytes),
+		zap.Int("shards", config.Shards),
+		zap.String("eviction_policy", config.EvictionPolicy.String()),
 		zap.Duration("default_ttl", config.DefaultTTL),
 		zap.Duration("cleanup_interval", config.CleanupInterval),
 	)
@@ -117,46 +198,36 @@ func (c *MemoryCache) Get(ctx context.Context, key string) (interface{}, error)
 		return nil, err
 	}
 
-	c.mu.RLock()
-	entry, exists := c.data[key]
-	c.mu.RUnlock()
+	e, expired := c.shardFor(key).get(key, time.Now())
 
-	if !exists {
-		c.logger.Debug("cache miss - key not found",
+	if expired != nil {
+		atomic.AddInt64(&c.expirations, 1)
+		c.logger.Debug("cache miss - key expired",
 			zap.String("key", key),
+			zap.Time("expired_at", expired.expiresAt),
 		)
+		c.notifyEvict(expired, EvictionReasonExpired)
 		return nil, cache.ErrKeyNotFound
 	}
 
-	// Check if expired
-	if time.Now().After(entry.expiresAt) {
-		c.logger.Debug("cache miss - key expired",
+	if e == nil {
+		c.logger.Debug("cache miss - key not found",
 			zap.String("key", key),
-			zap.Time("expired_at", entry.expiresAt),
 		)
-		// Remove expired entry
-		c.mu.Lock()
-		delete(c.data, key)
-		c.mu.Unlock()
 		return nil, cache.ErrKeyNotFound
 	}
 
 	c.logger.Debug("cache hit",
 		zap.String("key", key),
-		zap.Duration("ttl_remaining", time.Until(entry.expiresAt)),
+		zap.Duration("ttl_remaining", time.Until(e.expiresAt)),
 	)
 
-	// Update access time for LRU
-	c.mu.Lock()
-	entry.accessedAt = time.Now()
-	c.mu.Unlock()
-
-	return entry.value, nil
+	return e.value, nil
 }
 
 // Set stores a value in the cache with the specified TTL.
 // If ttl is 0, uses the default TTL.
-// Enforces MaxSize by evicting LRU entries if necessary.
+// Enforces MaxSize by evicting entries chosen by the eviction policy.
 func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	if err := validateKey(key); err != nil {
 		c.logger.Debug("invalid key",
@@ -171,53 +242,143 @@ func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, tt
 		ttl = c.config.DefaultTTL
 	}
 
-	expiresAt := time.Now().Add(ttl)
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	c.logger.Debug("cache set",
+		zap.String("key", key),
+		zap.Duration("ttl", ttl),
+		zap.Time("expires_at", expiresAt),
+	)
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.store(&entry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+		createdAt: now,
+		version:   now.UnixNano(), // Simple versioning
+	})
+}
 
-	// Check if we need to evict for LRU
-	if c.config.MaxSize > 0 && len(c.data) >= c.config.MaxSize {
-		// Find the least recently used entry
-		var lruKey string
-		var lruTime time.Time
+// GetEntry retrieves a value along with its expiry, stale time, version and
+// creation time. Returns cache.ErrKeyNotFound if the key is missing or expired.
+func (c *MemoryCache) GetEntry(ctx context.Context, key string) (*cache.CacheEntry, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
 
-		for k, e := range c.data {
-			if lruKey == "" || e.accessedAt.Before(lruTime) {
-				lruKey = k
-				lruTime = e.accessedAt
-			}
-		}
+	e, expired := c.shardFor(key).get(key, time.Now())
 
-		// Evict LRU entry
-		if lruKey != "" {
-			c.logger.Debug("evicting LRU entry",
-				zap.String("evicted_key", lruKey),
-				zap.String("new_key", key),
-				zap.Int("cache_size", len(c.data)),
-			)
-			delete(c.data, lruKey)
-		}
+	if expired != nil {
+		atomic.AddInt64(&c.expirations, 1)
+		c.notifyEvict(expired, EvictionReasonExpired)
+		return nil, cache.ErrKeyNotFound
+	}
+	if e == nil {
+		return nil, cache.ErrKeyNotFound
 	}
 
-	c.logger.Debug("cache set",
-		zap.String("key", key),
-		zap.Duration("ttl", ttl),
-		zap.Time("expires_at", expiresAt),
+	return &cache.CacheEntry{
+		Key:          e.key,
+		Value:        e.value,
+		ExpiresAt:    e.expiresAt,
+		StaleAt:      e.staleAt,
+		Version:      e.version,
+		CreatedAt:    e.createdAt,
+		LoadDuration: e.loadTime,
+	}, nil
+}
+
+// SetEntry stores a value with its metadata. The entry expires at
+// entry.ExpiresAt (now + DefaultTTL if zero). A zero Version or CreatedAt
+// is filled in with the current time.
+func (c *MemoryCache) SetEntry(ctx context.Context, entry *cache.CacheEntry) error {
+	if err := validateKey(entry.Key); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	e := newEntryFrom(entry, now)
+	if e.expiresAt.IsZero() {
+		e.expiresAt = now.Add(c.config.DefaultTTL)
+	}
+
+	c.logger.Debug("cache set entry",
+		zap.String("key", e.key),
+		zap.Time("expires_at", e.expiresAt),
+		zap.Time("stale_at", e.staleAt),
 	)
 
-	// Store the entry
-	c.data[key] = &entry{
-		key:        key,
-		value:      value,
-		expiresAt:  expiresAt,
-		accessedAt: time.Now(),
-		version:    time.Now().UnixNano(), // Simple versioning
+	return c.store(e)
+}
+
+// newEntryFrom converts a cache.CacheEntry to an entry.
+func newEntryFrom(ce *cache.CacheEntry, now time.Time) *entry {
+	e := &entry{
+		key:       ce.Key,
+		value:     ce.Value,
+		expiresAt: ce.ExpiresAt,
+		staleAt:   ce.StaleAt,
+		createdAt: ce.CreatedAt,
+		version:   ce.Version,
+		loadTime:  ce.LoadDuration,
+	}
+	if e.createdAt.IsZero() {
+		e.createdAt = now
+	}
+	if e.version == 0 {
+		e.version = now.UnixNano()
+	}
+	return e
+}
+
+// store sizes the entry, inserts it into its shard and reports evictions.
+func (c *MemoryCache) store(e *entry) error {
+	if c.config.Sizer != nil {
+		e.size = int64(entryOverhead + len(e.key) + c.config.Sizer(e.value))
+	}
+
+	s := c.shardFor(e.key)
+	if s.maxBytes > 0 && e.size > s.maxBytes {
+		c.logger.Debug("value exceeds byte budget",
+			zap.String("key", e.key),
+			zap.Int64("size", e.size),
+			zap.Int64("max_bytes", s.maxBytes),
+		)
+		return fmt.Errorf("%w: entry of %d bytes exceeds the per-shard budget of %d bytes", cache.ErrInvalidValue, e.size, s.maxBytes)
+	}
+
+	evicted := s.set(e)
+
+	for _, victim := range evicted {
+		atomic.AddInt64(&c.evictions, 1)
+		c.logger.Debug("evicting entry",
+			zap.String("evicted_key", victim.key),
+			zap.String("new_key", e.key),
+			zap.String("policy", c.config.EvictionPolicy.String()),
+		)
+		c.notifyEvict(victim, EvictionReasonCapacity)
 	}
 
 	return nil
 }
 
+// shardFor returns the shard owning key.
+func (c *MemoryCache) shardFor(key string) *shard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[maphash.String(c.seed, key)%uint64(len(c.shards))]
+}
+
+// notifyEvict invokes the OnEvict callback, if configured.
+// Must be called without any shard lock held.
+func (c *MemoryCache) notifyEvict(e *entry, reason EvictionReason) {
+	if c.config.OnEvict != nil {
+		c.config.OnEvict(e.key, e.value, reason)
+	}
+}
+
 // Delete removes a key from the cache.
 // Returns nil even if the key doesn't exist.
 func (c *MemoryCache) Delete(ctx context.Context, key string) error {
@@ -229,10 +390,7 @@ func (c *MemoryCache) Delete(ctx context.Context, key string) error {
 		return err
 	}
 
-	c.mu.Lock()
-	_, exists := c.data[key]
-	delete(c.data, key)
-	c.mu.Unlock()
+	exists := c.shardFor(key).delete(key)
 
 	c.logger.Debug("cache delete",
 		zap.String("key", key),
@@ -259,9 +417,9 @@ func (c *MemoryCache) Close() error {
 	c.wg.Wait()
 
 	// Clear data
-	c.mu.Lock()
-	c.data = nil
-	c.mu.Unlock()
+	for _, s := range c.shards {
+		s.clear()
+	}
 
 	return nil
 }
@@ -281,27 +439,40 @@ func (c *MemoryCache) cleanup() {
 }
 
 // removeExpired removes all expired entries from the cache.
+// Shards are swept one at a time, so only one shard is locked at any moment.
 func (c *MemoryCache) removeExpired() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	for _, s := range c.shards {
+		expired := s.removeExpired(time.Now())
 
-	now := time.Now()
-	for key, entry := range c.data {
-		if now.After(entry.expiresAt) {
-			delete(c.data, key)
+		atomic.AddInt64(&c.expirations, int64(len(expired)))
+		for _, e := range expired {
+			c.notifyEvict(e, EvictionReasonExpired)
 		}
 	}
 }
 
 // Stats returns current cache statistics.
 func (c *MemoryCache) Stats() MemoryCacheStats {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	var size, tags int
+	var bytes int64
+	for _, s := range c.shards {
+		n, b := s.usage()
+		size += n
+		bytes += b
+		tags += s.tagCount()
+	}
 
 	stats := MemoryCacheStats{
-		Size:     len(c.data),
-		MaxSize:  c.config.MaxSize,
-		Capacity: c.config.MaxSize,
+		Size:           size,
+		MaxSize:        c.config.MaxSize,
+		Capacity:       c.config.MaxSize,
+		Shards:         len(c.shards),
+		Bytes:          bytes,
+		Tags:           tags,
+		MaxBytes:       c.config.MaxBytes,
+		EvictionPolicy: c.config.EvictionPolicy,
+		Evictions:      atomic.LoadInt64(&c.evictions),
+		Expirations:    atomic.LoadInt64(&c.expirations),
 	}
 
 	if stats.Capacity == 0 {
@@ -313,9 +484,16 @@ func (c *MemoryCache) Stats() MemoryCacheStats {
 
 // MemoryCacheStats holds cache statistics.
 type MemoryCacheStats struct {
-	Size     int // Current number of entries
-	MaxSize  int // Maximum allowed entries (0 = unlimited)
-	Capacity int // Effective capacity (-1 = unlimited)
+	Size           int            // Current number of entries
+	MaxSize        int            // Maximum allowed entries (0 = unlimited)
+	Capacity       int            // Effective capacity (-1 = unlimited)
+	Shards         int            // Number of independently locked partitions
+	Bytes          int64          // Estimated memory used by entries (0 if sizes aren't tracked)
+	MaxBytes       int64          // Maximum estimated memory (0 = unlimited)
+	Tags           int            // Tags with at least one live entry (summed per shard)
+	EvictionPolicy EvictionPolicy // Policy used to pick eviction victims
+	Evictions      int64          // Entries evicted to make room
+	Expirations    int64          // Entries removed after their TTL elapsed
 }
 
 // validateKey checks if a cache key is valid.
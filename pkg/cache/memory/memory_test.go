@@ -2,10 +2,15 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"cache-chain/pkg/cache"
 )
 
 func TestMemoryCache_Get(t *testing.T) {
@@ -321,6 +326,135 @@ func TestMemoryCache_UnlimitedSize(t *testing.T) {
 	}
 }
 
+func TestMemoryCache_Shards(t *testing.T) {
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		MaxSize:         100,
+		Shards:          8,
+		DefaultTTL:      time.Hour,
+		CleanupInterval: time.Minute,
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 1000; i++ {
+		key := "key" + strconv.Itoa(i)
+		if err := cache.Set(ctx, key, i, 0); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		// The most recent write is always readable
+		if value, err := cache.Get(ctx, key); err != nil || value != i {
+			t.Errorf("Expected %d for %s, got %v (err: %v)", i, key, value, err)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Shards != 8 {
+		t.Errorf("Expected 8 shards, got %d", stats.Shards)
+	}
+	if stats.Size > 100 {
+		t.Errorf("Expected size at most 100, got %d", stats.Size)
+	}
+	if stats.Evictions != int64(1000-stats.Size) {
+		t.Errorf("Expected %d evictions, got %d", 1000-stats.Size, stats.Evictions)
+	}
+
+	// Every shard gets a share of the keys
+	for i, s := range cache.shards {
+		if n, _ := s.usage(); n == 0 {
+			t.Errorf("Expected shard %d to hold entries", i)
+		}
+	}
+
+	if err := cache.Delete(ctx, "key999"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if _, err := cache.Get(ctx, "key999"); err == nil {
+		t.Error("Expected miss after delete")
+	}
+}
+
+func TestMemoryCache_ConcurrentReads(t *testing.T) {
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:    "test",
+		MaxSize: 3,
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+	cache.Set(ctx, "a", "v", 0)
+	cache.Set(ctx, "b", "v", 0)
+	cache.Set(ctx, "c", "v", 0)
+
+	// Readers share the shard; their accesses overflow the read buffer
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := cache.Get(ctx, "a"); err != nil {
+					t.Errorf("Get failed: %v", err)
+					return
+				}
+				cache.Get(ctx, "c")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The buffered reads still count: b is the least recently used
+	cache.Set(ctx, "d", "v", 0)
+	if _, err := cache.Get(ctx, "b"); err == nil {
+		t.Error("Expected b to be evicted")
+	}
+	if _, err := cache.Get(ctx, "a"); err != nil {
+		t.Errorf("Expected a to survive, got %v", err)
+	}
+}
+
+func TestMemoryCache_ShardsCappedAtMaxSize(t *testing.T) {
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		MaxSize:         3,
+		Shards:          16,
+		DefaultTTL:      time.Hour,
+		CleanupInterval: time.Minute,
+	})
+	defer cache.Close()
+
+	if stats := cache.Stats(); stats.Shards != 3 {
+		t.Errorf("Expected shards capped at 3, got %d", stats.Shards)
+	}
+}
+
+func TestMemoryCache_ShardsCleanup(t *testing.T) {
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		Shards:          4,
+		DefaultTTL:      time.Hour,
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		cache.Set(ctx, "key"+strconv.Itoa(i), i, 20*time.Millisecond)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	stats := cache.Stats()
+	if stats.Size != 0 {
+		t.Errorf("Expected all entries cleaned up, got %d", stats.Size)
+	}
+	if stats.Expirations != 100 {
+		t.Errorf("Expected 100 expirations, got %d", stats.Expirations)
+	}
+}
+
 func BenchmarkMemoryCache_Get(b *testing.B) {
 	cache := NewMemoryCache(MemoryCacheConfig{
 		Name:            "bench",
@@ -367,3 +501,114 @@ func BenchmarkMemoryCache_Set(b *testing.B) {
 		}
 	})
 }
+
+// benchmarkSharded runs op in parallel against caches with different shard
+// counts at different GOMAXPROCS values, to show how throughput scales.
+func benchmarkSharded(b *testing.B, op func(cache *MemoryCache, ctx context.Context, key string)) {
+	const keys = 10000
+
+	keyNames := make([]string, keys)
+	for i := range keyNames {
+		keyNames[i] = "key" + strconv.Itoa(i)
+	}
+
+	for _, shards := range []int{1, 16, 64} {
+		for _, procs := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("shards=%d/procs=%d", shards, procs), func(b *testing.B) {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+				cache := NewMemoryCache(MemoryCacheConfig{
+					Name:            "bench",
+					Shards:          shards,
+					DefaultTTL:      time.Hour,
+					CleanupInterval: time.Minute,
+				})
+				defer cache.Close()
+
+				ctx := context.Background()
+				for i, key := range keyNames {
+					cache.Set(ctx, key, i, 0)
+				}
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						op(cache, ctx, keyNames[i%keys])
+						i++
+					}
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkMemoryCache_ShardedGet(b *testing.B) {
+	benchmarkSharded(b, func(cache *MemoryCache, ctx context.Context, key string) {
+		cache.Get(ctx, key)
+	})
+}
+
+func BenchmarkMemoryCache_ShardedSet(b *testing.B) {
+	benchmarkSharded(b, func(cache *MemoryCache, ctx context.Context, key string) {
+		cache.Set(ctx, key, key, 0)
+	})
+}
+
+func TestMemoryCache_Entry(t *testing.T) {
+	c := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		DefaultTTL:      time.Hour,
+		CleanupInterval: time.Minute,
+	})
+	defer c.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	err := c.SetEntry(ctx, &cache.CacheEntry{
+		Key:          "key",
+		Value:        "value",
+		StaleAt:      now.Add(-time.Second),
+		ExpiresAt:    now.Add(time.Minute),
+		LoadDuration: 42 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("SetEntry failed: %v", err)
+	}
+
+	entry, err := c.GetEntry(ctx, "key")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if entry.Value != "value" || !entry.IsStale() {
+		t.Errorf("Expected stale entry with value, got %+v", entry)
+	}
+	if entry.LoadDuration != 42*time.Millisecond {
+		t.Errorf("Expected load duration 42ms, got %v", entry.LoadDuration)
+	}
+	if entry.Version == 0 || entry.CreatedAt.IsZero() {
+		t.Errorf("Expected version and creation time to be filled in, got %+v", entry)
+	}
+
+	// Stale entries are still served by Get until they expire
+	if value, err := c.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("Expected Get to return stale value, got %v (err: %v)", value, err)
+	}
+
+	// Values stored with Set have an expiry but are never stale
+	c.Set(ctx, "plain", "value", time.Minute)
+	entry, err = c.GetEntry(ctx, "plain")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if entry.IsStale() || entry.TimeToLive() <= 0 {
+		t.Errorf("Expected fresh entry with TTL, got %+v", entry)
+	}
+
+	// Expired entries are misses
+	c.SetEntry(ctx, &cache.CacheEntry{Key: "expired", Value: "value", ExpiresAt: now.Add(-time.Second)})
+	if _, err := c.GetEntry(ctx, "expired"); !cache.IsNotFound(err) {
+		t.Errorf("Expected miss for expired entry, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"cache-chain/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// prefixBatchSize is the number of keys removed per shard lock in DeletePrefix.
+const prefixBatchSize = 1000
+
+// DeletePrefix removes every key starting with prefix and returns how many
+// were removed. With PrefixIndex enabled the matching keys are found in a
+// radix tree; otherwise every entry is scanned. Keys are removed in batches,
+// each under a short shard lock, and onBatch (if not nil) receives the keys of
+// each batch. An empty prefix is rejected.
+func (c *MemoryCache) DeletePrefix(ctx context.Context, prefix string, onBatch func(keys []string)) (int, error) {
+	if prefix == "" {
+		return 0, cache.ErrInvalidKey
+	}
+
+	deleted := 0
+	for _, s := range c.shards {
+		keys := s.matchPrefix(prefix)
+		for start := 0; start < len(keys); start += prefixBatchSize {
+			if err := ctx.Err(); err != nil {
+				return deleted, err
+			}
+
+			end := min(start+prefixBatchSize, len(keys))
+			removed := s.deleteKeys(keys[start:end])
+			deleted += len(removed)
+			if onBatch != nil && len(removed) > 0 {
+				onBatch(removed)
+			}
+		}
+	}
+
+	c.logger.Debug("prefix deleted",
+		zap.String("prefix", prefix),
+		zap.Int("keys", deleted),
+		zap.Bool("indexed", c.config.PrefixIndex),
+	)
+
+	return deleted, nil
+}
+
+// matchPrefix returns the keys of the shard starting with prefix.
+func (s *shard) matchPrefix(prefix string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+	if s.prefixes != nil {
+		s.prefixes.walkPrefix(prefix, func(key string) {
+			keys = append(keys, key)
+		})
+		return keys
+	}
+
+	for key := range s.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// deleteKeys removes the given keys and returns those that existed.
+func (s *shard) deleteKeys(keys []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := keys[:0:0]
+	for _, key := range keys {
+		if e, ok := s.data[key]; ok {
+			s.remove(e)
+			removed = append(removed, key)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+var _ cache.PrefixLayer = (*MemoryCache)(nil)
+
+func TestMemoryCache_DeletePrefix(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("indexed=%v", indexed), func(t *testing.T) {
+			c := NewMemoryCache(MemoryCacheConfig{Name: "test", Shards: 4, PrefixIndex: indexed})
+			defer c.Close()
+
+			ctx := context.Background()
+			for i := 0; i < 2500; i++ {
+				c.Set(ctx, fmt.Sprintf("user:%d", i), i, time.Minute)
+			}
+			c.Set(ctx, "users", "other", time.Minute)
+			c.Set(ctx, "order:1", "other", time.Minute)
+
+			var batches, reported int
+			deleted, err := c.DeletePrefix(ctx, "user:", func(keys []string) {
+				batches++
+				reported += len(keys)
+				if len(keys) > prefixBatchSize {
+					t.Errorf("Expected batches of at most %d keys, got %d", prefixBatchSize, len(keys))
+				}
+			})
+			if err != nil {
+				t.Fatalf("DeletePrefix failed: %v", err)
+			}
+			if deleted != 2500 || reported != 2500 {
+				t.Errorf("Expected 2500 deleted and reported, got %d and %d", deleted, reported)
+			}
+			if batches < 4 {
+				t.Errorf("Expected at least one batch per shard, got %d", batches)
+			}
+
+			if _, err := c.Get(ctx, "user:42"); err != cache.ErrKeyNotFound {
+				t.Errorf("Expected user:42 to be deleted, got %v", err)
+			}
+			if stats := c.Stats(); stats.Size != 2 {
+				t.Errorf("Expected 2 remaining entries, got %d", stats.Size)
+			}
+		})
+	}
+}
+
+func TestMemoryCache_DeletePrefix_Invalid(t *testing.T) {
+	c := NewMemoryCache(MemoryCacheConfig{Name: "test"})
+	defer c.Close()
+
+	if _, err := c.DeletePrefix(context.Background(), "", nil); err != cache.ErrInvalidKey {
+		t.Errorf("Expected ErrInvalidKey for empty prefix, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Set(ctx, "user:1", 1, time.Minute)
+	cancel()
+	if _, err := c.DeletePrefix(ctx, "user:", nil); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMemoryCache_PrefixIndexTracksRemovals(t *testing.T) {
+	c := NewMemoryCache(MemoryCacheConfig{Name: "test", MaxSize: 2, PrefixIndex: true})
+	defer c.Close()
+
+	ctx := context.Background()
+	c.Set(ctx, "user:1", 1, time.Minute)
+	c.Set(ctx, "user:2", 2, time.Minute)
+	c.Set(ctx, "user:3", 3, time.Minute) // evicts user:1
+	c.Set(ctx, "user:4", 4, 10*time.Millisecond)
+	c.Delete(ctx, "user:3")
+
+	time.Sleep(20 * time.Millisecond)
+	c.removeExpired()
+
+	var keys []string
+	c.DeletePrefix(ctx, "user:", func(batch []string) {
+		keys = append(keys, batch...)
+	})
+	if len(keys) != 0 {
+		t.Errorf("Expected removed entries to leave the index, got %v", keys)
+	}
+}
@@ -0,0 +1,162 @@
+package memory
+
+import "strings"
+
+// radixTree is a compressed trie of keys, used as a prefix index so
+// DeletePrefix can find matching keys without scanning every entry.
+// It is not safe for concurrent use; shards guard it with their lock.
+type radixTree struct {
+	root radixNode
+	size int
+}
+
+// radixNode is a node of a radixTree. label is the edge from its parent;
+// the node holds a key if leaf is set.
+type radixNode struct {
+	label    string
+	leaf     bool
+	children map[byte]*radixNode
+}
+
+func newRadixTree() *radixTree {
+	return &radixTree{}
+}
+
+// insert adds key to the tree.
+func (t *radixTree) insert(key string) {
+	n := &t.root
+	search := key
+	for {
+		if search == "" {
+			if !n.leaf {
+				n.leaf = true
+				t.size++
+			}
+			return
+		}
+
+		child := n.children[search[0]]
+		if child == nil {
+			n.addChild(&radixNode{label: search, leaf: true})
+			t.size++
+			return
+		}
+
+		common := commonPrefixLen(search, child.label)
+		if common == len(child.label) {
+			n = child
+			search = search[common:]
+			continue
+		}
+
+		// Split the edge at the common prefix
+		split := &radixNode{label: search[:common]}
+		n.children[search[0]] = split
+		child.label = child.label[common:]
+		split.addChild(child)
+
+		search = search[common:]
+		if search == "" {
+			split.leaf = true
+		} else {
+			split.addChild(&radixNode{label: search, leaf: true})
+		}
+		t.size++
+		return
+	}
+}
+
+// delete removes key from the tree, merging nodes left with a single child.
+func (t *radixTree) delete(key string) {
+	var parent *radixNode
+	n := &t.root
+	search := key
+	for search != "" {
+		child := n.children[search[0]]
+		if child == nil || !strings.HasPrefix(search, child.label) {
+			return
+		}
+		parent = n
+		n = child
+		search = search[len(child.label):]
+	}
+	if !n.leaf {
+		return
+	}
+	n.leaf = false
+	t.size--
+
+	if n == &t.root {
+		return
+	}
+	if len(n.children) == 0 {
+		delete(parent.children, n.label[0])
+		// The parent may now be a pass-through node
+		if parent != &t.root && !parent.leaf && len(parent.children) == 1 {
+			parent.mergeChild()
+		}
+		return
+	}
+	if len(n.children) == 1 {
+		n.mergeChild()
+	}
+}
+
+// walkPrefix calls fn for every key starting with prefix.
+func (t *radixTree) walkPrefix(prefix string, fn func(key string)) {
+	n := &t.root
+	path := ""
+	search := prefix
+	for search != "" {
+		child := n.children[search[0]]
+		if child == nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(search, child.label):
+			search = search[len(child.label):]
+		case strings.HasPrefix(child.label, search):
+			// The prefix ends inside this edge
+			search = ""
+		default:
+			return
+		}
+		path += child.label
+		n = child
+	}
+	n.walk(path, fn)
+}
+
+// walk calls fn for every key under n, whose path from the root is path.
+func (n *radixNode) walk(path string, fn func(key string)) {
+	if n.leaf {
+		fn(path)
+	}
+	for _, child := range n.children {
+		child.walk(path+child.label, fn)
+	}
+}
+
+func (n *radixNode) addChild(child *radixNode) {
+	if n.children == nil {
+		n.children = make(map[byte]*radixNode)
+	}
+	n.children[child.label[0]] = child
+}
+
+// mergeChild absorbs the node's only child.
+func (n *radixNode) mergeChild() {
+	for _, child := range n.children {
+		n.label += child.label
+		n.leaf = child.leaf
+		n.children = child.children
+	}
+}
+
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
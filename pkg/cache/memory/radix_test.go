@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func collectPrefix(t *radixTree, prefix string) []string {
+	var keys []string
+	t.walkPrefix(prefix, func(key string) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+func TestRadixTree(t *testing.T) {
+	tree := newRadixTree()
+	for _, key := range []string{"user:1", "user:10", "user:2", "users", "u", "order:1"} {
+		tree.insert(key)
+	}
+	tree.insert("user:1") // duplicate
+
+	if tree.size != 6 {
+		t.Errorf("Expected size 6, got %d", tree.size)
+	}
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"user:", []string{"user:1", "user:10", "user:2"}},
+		{"user:1", []string{"user:1", "user:10"}},
+		{"use", []string{"user:1", "user:10", "user:2", "users"}},
+		{"u", []string{"u", "user:1", "user:10", "user:2", "users"}},
+		{"order:", []string{"order:1"}},
+		{"user:3", nil},
+		{"x", nil},
+	}
+	for _, tt := range tests {
+		got := collectPrefix(tree, tt.prefix)
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("walkPrefix(%q) = %v, want %v", tt.prefix, got, tt.expected)
+		}
+	}
+
+	tree.delete("user:1")
+	tree.delete("user:3") // missing
+	tree.delete("use")    // inner node, not a key
+	if got := collectPrefix(tree, "user:"); strings.Join(got, ",") != "user:10,user:2" {
+		t.Errorf("Expected [user:10 user:2] after delete, got %v", got)
+	}
+	if tree.size != 5 {
+		t.Errorf("Expected size 5, got %d", tree.size)
+	}
+}
+
+func TestRadixTree_RandomOperations(t *testing.T) {
+	tree := newRadixTree()
+	live := make(map[string]bool)
+	rng := rand.New(rand.NewPCG(1, 2))
+	alphabet := "ab:"
+
+	randomKey := func() string {
+		b := make([]byte, 1+rng.IntN(6))
+		for i := range b {
+			b[i] = alphabet[rng.IntN(len(alphabet))]
+		}
+		return string(b)
+	}
+
+	for i := 0; i < 5000; i++ {
+		key := randomKey()
+		if rng.IntN(3) == 0 {
+			tree.delete(key)
+			delete(live, key)
+		} else {
+			tree.insert(key)
+			live[key] = true
+		}
+	}
+
+	if tree.size != len(live) {
+		t.Errorf("Expected size %d, got %d", len(live), tree.size)
+	}
+
+	for _, prefix := range []string{"a", "ab", "b:", ":a", "aba", "bb:a"} {
+		var expected []string
+		for key := range live {
+			if strings.HasPrefix(key, prefix) {
+				expected = append(expected, key)
+			}
+		}
+		sort.Strings(expected)
+
+		got := collectPrefix(tree, prefix)
+		if strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("walkPrefix(%q) = %v, want %v", prefix, got, expected)
+		}
+	}
+}
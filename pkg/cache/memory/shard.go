@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+// readBufferSize is the number of accesses a shard buffers before applying
+// them to its eviction policy.
+const readBufferSize = 64
+
+// shard is an independently locked partition of a MemoryCache.
+// Each shard owns its entries, its eviction policy and its share of MaxSize,
+// so operations on keys in different shards never contend. Reads only take
+// the read lock: their accesses are buffered and applied to the policy under
+// the write lock, before the next write or once the buffer fills up.
+type shard struct {
+	// mu protects data and policy
+	mu sync.RWMutex
+
+	// reads buffers the entries read since the policy was last updated
+	reads chan *entry
+
+	// data stores the shard's entries
+	data map[string]*entry
+
+	// policy tracks entries and picks eviction victims
+	policy evictionPolicy
+
+	// bytes is the total size of the entries
+	bytes int64
+
+	// tags maps each tag to the keys of this shard carrying it
+	tags map[string]map[string]struct{}
+
+	// prefixes indexes the shard's keys by prefix (nil when disabled)
+	prefixes *radixTree
+
+	// evictionPolicy and maxSize are kept to rebuild the policy on reset
+	evictionPolicy EvictionPolicy
+	maxSize        int   // 0 = unlimited
+	maxBytes       int64 // 0 = unlimited
+}
+
+func newShard(policy EvictionPolicy, maxSize int, maxBytes int64, prefixIndex bool) *shard {
+	s := &shard{
+		data:           make(map[string]*entry),
+		reads:          make(chan *entry, readBufferSize),
+		tags:           make(map[string]map[string]struct{}),
+		policy:         newEvictionPolicy(policy, maxSize),
+		evictionPolicy: policy,
+		maxSize:        maxSize,
+		maxBytes:       maxBytes,
+	}
+	if prefixIndex {
+		s.prefixes = newRadixTree()
+	}
+	return s
+}
+
+// get returns a copy of the live entry for key and records the access.
+// The copy can be read without holding the lock. An expired entry is
+// removed and returned as expired.
+func (s *shard) get(key string, now time.Time) (found *entry, expired *entry) {
+	s.mu.RLock()
+	e, exists := s.data[key]
+	if !exists {
+		s.mu.RUnlock()
+		return nil, nil
+	}
+	if now.After(e.expiresAt) {
+		s.mu.RUnlock()
+		return nil, s.expire(key, now)
+	}
+	snapshot := *e
+	s.mu.RUnlock()
+
+	s.access(e)
+	return &snapshot, nil
+}
+
+// expire removes key if it is expired at now and returns the removed entry.
+func (s *shard) expire(key string, now time.Time) *entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, exists := s.data[key]
+	if !exists || !now.After(e.expiresAt) {
+		return nil
+	}
+	s.remove(e)
+	return e
+}
+
+// access records a read of e without blocking. When the buffer is full, the
+// reader applies it unless another goroutine holds the lock, in which case
+// the access is dropped: recency and frequency are approximate under load.
+func (s *shard) access(e *entry) {
+	select {
+	case s.reads <- e:
+		return
+	default:
+	}
+
+	if s.mu.TryLock() {
+		s.applyReads()
+		if s.data[e.key] == e {
+			s.policy.access(e)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// applyReads applies the buffered reads to the policy, skipping entries
+// removed or replaced since. Must be called with s.mu held.
+func (s *shard) applyReads() {
+	for {
+		select {
+		case e := <-s.reads:
+			if s.data[e.key] == e {
+				s.policy.access(e)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// set stores a new entry, overwriting in place if the key exists.
+// Returns the entries evicted to make room. The caller ensures the entry's
+// size fits within maxBytes.
+func (s *shard) set(n *entry) []*entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Bring recency up to date before picking victims
+	s.applyReads()
+
+	if e, exists := s.data[n.key]; exists {
+		// Overwrite in place: no eviction needed
+		if s.maxBytes == 0 || s.bytes-e.size+n.size <= s.maxBytes {
+			s.bytes += n.size - e.size
+			e.value = n.value
+			e.size = n.size
+			e.expiresAt = n.expiresAt
+			e.staleAt = n.staleAt
+			e.createdAt = n.createdAt
+			e.version = n.version
+			e.loadTime = n.loadTime
+			s.unindex(e)
+			e.tags = n.tags
+			s.index(e)
+			s.policy.access(e)
+			return nil
+		}
+
+		// The value grew past the byte budget: re-insert it so
+		// eviction can make room
+		s.remove(e)
+	}
+
+	// Make room for the new entry
+	var evicted []*entry
+	for s.full(n.size) {
+		victim := s.policy.victim()
+		if victim == nil {
+			break
+		}
+		s.remove(victim)
+		evicted = append(evicted, victim)
+	}
+
+	s.data[n.key] = n
+	if s.prefixes != nil {
+		s.prefixes.insert(n.key)
+	}
+	s.index(n)
+	s.policy.add(n)
+	s.bytes += n.size
+
+	return evicted
+}
+
+// full reports whether an entry of the given size can only be added after
+// evicting. Must be called with s.mu held.
+func (s *shard) full(size int64) bool {
+	if len(s.data) == 0 {
+		return false
+	}
+	if s.maxSize > 0 && len(s.data) >= s.maxSize {
+		return true
+	}
+	return s.maxBytes > 0 && s.bytes+size > s.maxBytes
+}
+
+// delete removes key and reports whether it existed.
+func (s *shard) delete(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, exists := s.data[key]
+	if exists {
+		s.remove(e)
+	}
+	return exists
+}
+
+// remove removes an entry from the map and the eviction policy.
+// Must be called with s.mu held.
+func (s *shard) remove(e *entry) {
+	s.unindex(e)
+	if s.prefixes != nil {
+		s.prefixes.delete(e.key)
+	}
+	s.policy.remove(e)
+	delete(s.data, e.key)
+	s.bytes -= e.size
+}
+
+// removeExpired removes and returns all entries expired at now.
+func (s *shard) removeExpired(now time.Time) []*entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*entry
+	for _, e := range s.data {
+		if now.After(e.expiresAt) {
+			s.remove(e)
+			expired = append(expired, e)
+		}
+	}
+	return expired
+}
+
+// usage returns the number of entries and their total size.
+func (s *shard) usage() (int, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data), s.bytes
+}
+
+// clear drops all entries.
+func (s *shard) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.reads) > 0 {
+		<-s.reads
+	}
+	s.data = nil
+	s.tags = nil
+	if s.prefixes != nil {
+		s.prefixes = newRadixTree()
+	}
+	s.bytes = 0
+	s.policy = newEvictionPolicy(s.evictionPolicy, s.maxSize)
+}
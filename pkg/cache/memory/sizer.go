@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"reflect"
+	"unsafe"
+
+	"cache-chain/pkg/cache"
+)
+
+// Sizer estimates the memory footprint of a cached value in bytes.
+// It drives MaxBytes enforcement and the Bytes statistic.
+type Sizer func(value interface{}) int
+
+// Sizeable is implemented by values that know their own size in bytes.
+// DefaultSizer uses it instead of estimating.
+type Sizeable interface {
+	Size() int
+}
+
+// entryOverhead approximates the per-entry bookkeeping cost
+// (entry struct, map slot and eviction list element).
+const entryOverhead = int(unsafe.Sizeof(entry{})) + 64
+
+// DefaultSizer returns value.Size() for Sizeable values, and otherwise
+// estimates the size by walking the value with reflection, following pointers,
+// slices, maps and interfaces. Shared pointers are counted once.
+func DefaultSizer(value interface{}) int {
+	if s, ok := value.(Sizeable); ok {
+		return s.Size()
+	}
+	if value == nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(value)
+	visited := make(map[uintptr]bool)
+	return int(v.Type().Size()) + indirectSize(v, visited)
+}
+
+// EncodedSizer returns a Sizer that measures the encoded length of values
+// with the given codec. It is slower than DefaultSizer but matches what a
+// serialized layer would store. Values that fail to encode fall back to
+// DefaultSizer.
+func EncodedSizer(codec cache.Codec) Sizer {
+	return func(value interface{}) int {
+		if s, ok := value.(Sizeable); ok {
+			return s.Size()
+		}
+		data, err := codec.Marshal(value)
+		if err != nil {
+			return DefaultSizer(value)
+		}
+		return len(data)
+	}
+}
+
+// indirectSize returns the bytes referenced by v beyond its inline size.
+func indirectSize(v reflect.Value, visited map[uintptr]bool) int {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || visited[v.Pointer()] {
+			return 0
+		}
+		visited[v.Pointer()] = true
+		elem := v.Elem()
+		return int(elem.Type().Size()) + indirectSize(elem, visited)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int(elem.Type().Size()) + indirectSize(elem, visited)
+
+	case reflect.String:
+		return v.Len()
+
+	case reflect.Slice:
+		if v.IsNil() || visited[v.Pointer()] {
+			return 0
+		}
+		visited[v.Pointer()] = true
+		size := v.Cap() * int(v.Type().Elem().Size())
+		if hasIndirect(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += indirectSize(v.Index(i), visited)
+			}
+		}
+		return size
+
+	case reflect.Array:
+		size := 0
+		if hasIndirect(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				size += indirectSize(v.Index(i), visited)
+			}
+		}
+		return size
+
+	case reflect.Struct:
+		size := 0
+		for i := 0; i < v.NumField(); i++ {
+			size += indirectSize(v.Field(i), visited)
+		}
+		return size
+
+	case reflect.Map:
+		if v.IsNil() || visited[v.Pointer()] {
+			return 0
+		}
+		visited[v.Pointer()] = true
+		keySize := int(v.Type().Key().Size())
+		elemSize := int(v.Type().Elem().Size())
+		size := 0
+		iter := v.MapRange()
+		for iter.Next() {
+			// One tophash byte per slot on top of the key and element
+			size += keySize + elemSize + 1
+			size += indirectSize(iter.Key(), visited)
+			size += indirectSize(iter.Value(), visited)
+		}
+		return size
+
+	default:
+		// Numbers and booleans are fully inline; channels, functions and
+		// unsafe pointers are not owned by the value
+		return 0
+	}
+}
+
+// hasIndirect reports whether values of type t can reference memory outside
+// their inline size.
+func hasIndirect(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.String, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Array:
+		return hasIndirect(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasIndirect(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
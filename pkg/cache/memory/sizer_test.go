@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+type sizedValue struct{ n int }
+
+func (v sizedValue) Size() int { return v.n }
+
+type node struct {
+	Name string
+	Next *node
+}
+
+func TestDefaultSizer(t *testing.T) {
+	cyclic := &node{Name: "a"}
+	cyclic.Next = cyclic
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		minSize int
+		maxSize int
+	}{
+		{"nil", nil, 0, 0},
+		{"sizeable", sizedValue{n: 12345}, 12345, 12345},
+		{"int", 42, 8, 8},
+		{"string", strings.Repeat("x", 1000), 1000, 1100},
+		{"bytes", make([]byte, 4096), 4096, 4200},
+		{"string slice", []string{strings.Repeat("x", 500), strings.Repeat("y", 500)}, 1000, 1200},
+		{"map", map[string]string{"k": strings.Repeat("v", 2000)}, 2000, 2200},
+		{"struct pointer", &node{Name: strings.Repeat("n", 300)}, 300, 400},
+		{"cycle", cyclic, 1, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := DefaultSizer(tt.value)
+			if size < tt.minSize || size > tt.maxSize {
+				t.Errorf("Expected size in [%d, %d], got %d", tt.minSize, tt.maxSize, size)
+			}
+		})
+	}
+}
+
+func TestEncodedSizer(t *testing.T) {
+	sizer := EncodedSizer(cache.JSONCodec{})
+
+	if size := sizer("abc"); size != 5 {
+		t.Errorf("Expected encoded size 5, got %d", size)
+	}
+	if size := sizer(sizedValue{n: 7}); size != 7 {
+		t.Errorf("Expected Size() to be used, got %d", size)
+	}
+}
+
+func TestMemoryCache_MaxBytes(t *testing.T) {
+	var evicted []string
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		MaxBytes:        10000,
+		Sizer:           func(value interface{}) int { return value.(sizedValue).n },
+		DefaultTTL:      time.Hour,
+		CleanupInterval: time.Minute,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			evicted = append(evicted, key)
+		},
+	})
+	defer cache.Close()
+
+	ctx := context.Background()
+	valueSize := 3000 - entryOverhead - len("key0")
+
+	// Three entries of 3000 bytes fit, the fourth evicts the oldest
+	for i := 0; i < 4; i++ {
+		if err := cache.Set(ctx, "key"+strconv.Itoa(i), sizedValue{n: valueSize}, 0); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	if len(evicted) != 1 || evicted[0] != "key0" {
+		t.Errorf("Expected key0 to be evicted, got %v", evicted)
+	}
+
+	stats := cache.Stats()
+	if stats.Bytes != 9000 {
+		t.Errorf("Expected 9000 bytes, got %d", stats.Bytes)
+	}
+	if stats.MaxBytes != 10000 {
+		t.Errorf("Expected max bytes 10000, got %d", stats.MaxBytes)
+	}
+
+	// Growing an entry past the budget evicts others, not the entry itself
+	if err := cache.Set(ctx, "key3", sizedValue{n: valueSize + 3000}, 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := cache.Get(ctx, "key3"); err != nil {
+		t.Errorf("Expected grown entry to be stored, got %v", err)
+	}
+	if stats := cache.Stats(); stats.Bytes > 10000 {
+		t.Errorf("Expected bytes within budget, got %d", stats.Bytes)
+	}
+
+	// Deleting releases its bytes
+	cache.Delete(ctx, "key3")
+	if stats := cache.Stats(); stats.Bytes > 3000 {
+		t.Errorf("Expected bytes released on delete, got %d", stats.Bytes)
+	}
+}
+
+func TestMemoryCache_MaxBytesRejectsOversized(t *testing.T) {
+	c := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		MaxBytes:        1000,
+		DefaultTTL:      time.Hour,
+		CleanupInterval: time.Minute,
+	})
+	defer c.Close()
+
+	ctx := context.Background()
+
+	err := c.Set(ctx, "big", strings.Repeat("x", 2000), 0)
+	if !errors.Is(err, cache.ErrInvalidValue) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+	if stats := c.Stats(); stats.Size != 0 || stats.Bytes != 0 {
+		t.Errorf("Expected nothing stored, got size %d and %d bytes", stats.Size, stats.Bytes)
+	}
+
+	// Small values still fit
+	if err := c.Set(ctx, "small", "x", 0); err != nil {
+		t.Errorf("Set failed: %v", err)
+	}
+}
+
+func TestMemoryCache_BytesNotTrackedByDefault(t *testing.T) {
+	cache := NewMemoryCache(MemoryCacheConfig{
+		Name:            "test",
+		DefaultTTL:      time.Hour,
+		CleanupInterval: time.Minute,
+	})
+	defer cache.Close()
+
+	cache.Set(context.Background(), "key", strings.Repeat("x", 1000), 0)
+
+	if stats := cache.Stats(); stats.Bytes != 0 {
+		t.Errorf("Expected bytes not tracked without MaxBytes or Sizer, got %d", stats.Bytes)
+	}
+}
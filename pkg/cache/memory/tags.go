@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SetWithTags stores a value like Set and attaches tags to it, so it can
+// later be removed with InvalidateTag. Writing the key again with Set or
+// SetEntry drops its tags. Tagged entries leave the index as soon as they
+// are deleted, evicted or expire.
+func (c *MemoryCache) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags []string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if ttl == 0 {
+		ttl = c.config.DefaultTTL
+	}
+
+	now := time.Now()
+
+	c.logger.Debug("cache set with tags",
+		zap.String("key", key),
+		zap.Duration("ttl", ttl),
+		zap.Strings("tags", tags),
+	)
+
+	return c.store(&entry{
+		key:       key,
+		value:     value,
+		expiresAt: now.Add(ttl),
+		createdAt: now,
+		version:   now.UnixNano(),
+		tags:      uniqueTags(tags),
+	})
+}
+
+// InvalidateTag removes every entry carrying tag and returns their keys.
+func (c *MemoryCache) InvalidateTag(ctx context.Context, tag string) ([]string, error) {
+	var keys []string
+	for _, s := range c.shards {
+		keys = append(keys, s.invalidateTag(tag)...)
+	}
+
+	c.logger.Debug("tag invalidated",
+		zap.String("tag", tag),
+		zap.Int("keys", len(keys)),
+	)
+
+	return keys, nil
+}
+
+// index adds the entry's key to its tags. Must be called with s.mu held.
+func (s *shard) index(e *entry) {
+	for _, tag := range e.tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[e.key] = struct{}{}
+	}
+}
+
+// unindex removes the entry's key from its tags, dropping tags left empty.
+// Must be called with s.mu held.
+func (s *shard) unindex(e *entry) {
+	for _, tag := range e.tags {
+		keys := s.tags[tag]
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+// invalidateTag removes the entries carrying tag and returns their keys.
+func (s *shard) invalidateTag(tag string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tagged := s.tags[tag]
+	keys := make([]string, 0, len(tagged))
+	for key := range tagged {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if e, ok := s.data[key]; ok {
+			s.remove(e)
+		}
+	}
+	return keys
+}
+
+// tagCount returns the number of tags indexed in the shard.
+func (s *shard) tagCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tags)
+}
+
+// uniqueTags returns tags without duplicates or empty tags.
+func uniqueTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(tags))
+	unique := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		unique = append(unique, tag)
+	}
+	return unique
+}
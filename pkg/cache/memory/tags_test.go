@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+var _ cache.TaggedLayer = (*MemoryCache)(nil)
+
+func TestMemoryCache_InvalidateTag(t *testing.T) {
+	c := NewMemoryCache(MemoryCacheConfig{Name: "test", Shards: 4})
+	defer c.Close()
+
+	ctx := context.Background()
+	c.SetWithTags(ctx, "order:1", "a", time.Minute, []string{"account:42"})
+	c.SetWithTags(ctx, "order:2", "b", time.Minute, []string{"account:42", "region:eu"})
+	c.SetWithTags(ctx, "order:3", "c", time.Minute, []string{"region:eu"})
+	c.Set(ctx, "order:4", "d", time.Minute)
+
+	keys, err := c.InvalidateTag(ctx, "account:42")
+	if err != nil {
+		t.Fatalf("InvalidateTag failed: %v", err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "order:1" || keys[1] != "order:2" {
+		t.Errorf("Expected [order:1 order:2], got %v", keys)
+	}
+
+	for _, key := range []string{"order:1", "order:2"} {
+		if _, err := c.Get(ctx, key); err != cache.ErrKeyNotFound {
+			t.Errorf("Expected %s to be invalidated, got %v", key, err)
+		}
+	}
+	for _, key := range []string{"order:3", "order:4"} {
+		if _, err := c.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to survive, got %v", key, err)
+		}
+	}
+
+	// order:2 left the region:eu index along with its entry
+	keys, _ = c.InvalidateTag(ctx, "region:eu")
+	if len(keys) != 1 || keys[0] != "order:3" {
+		t.Errorf("Expected [order:3], got %v", keys)
+	}
+
+	if keys, _ := c.InvalidateTag(ctx, "unknown"); len(keys) != 0 {
+		t.Errorf("Expected no keys for unknown tag, got %v", keys)
+	}
+}
+
+func TestMemoryCache_TagIndexCleanup(t *testing.T) {
+	c := NewMemoryCache(MemoryCacheConfig{Name: "test", MaxSize: 2})
+	defer c.Close()
+
+	ctx := context.Background()
+
+	// Expiry
+	c.SetWithTags(ctx, "short", "v", 10*time.Millisecond, []string{"t1"})
+	time.Sleep(20 * time.Millisecond)
+	c.removeExpired()
+	if tags := c.Stats().Tags; tags != 0 {
+		t.Errorf("Expected expired entry to leave the index, got %d tags", tags)
+	}
+
+	// Eviction
+	c.SetWithTags(ctx, "a", "v", time.Minute, []string{"t2"})
+	c.Set(ctx, "b", "v", time.Minute)
+	c.Set(ctx, "c", "v", time.Minute)
+	if tags := c.Stats().Tags; tags != 0 {
+		t.Errorf("Expected evicted entry to leave the index, got %d tags", tags)
+	}
+
+	// Overwrite without tags and delete
+	c.SetWithTags(ctx, "b", "v", time.Minute, []string{"t3"})
+	c.SetWithTags(ctx, "c", "v", time.Minute, []string{"t4"})
+	c.Set(ctx, "b", "v2", time.Minute)
+	c.Delete(ctx, "c")
+	if tags := c.Stats().Tags; tags != 0 {
+		t.Errorf("Expected overwritten and deleted entries to leave the index, got %d tags", tags)
+	}
+	if keys, _ := c.InvalidateTag(ctx, "t3"); len(keys) != 0 {
+		t.Errorf("Expected overwritten key to lose its tags, got %v", keys)
+	}
+}
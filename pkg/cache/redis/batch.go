@@ -0,0 +1,273 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cache-chain/pkg/cache"
+
+	"github.com/redis/rueidis"
+	"go.uber.org/zap"
+)
+
+// slotGroup is a set of keys sharing a cluster hash slot.
+type slotGroup struct {
+	keys     []string // keys as given by the caller
+	fullKeys []string // keys with KeyPrefix
+}
+
+// groupBySlot splits keys into groups that multi-key commands can address.
+// Outside cluster mode all keys form a single group.
+func (r *RedisCache) groupBySlot(keys []string) []*slotGroup {
+	if !r.cluster {
+		g := &slotGroup{keys: keys, fullKeys: make([]string, len(keys))}
+		for i, key := range keys {
+			g.fullKeys[i] = r.config.KeyPrefix + key
+		}
+		return []*slotGroup{g}
+	}
+
+	bySlot := make(map[uint16]*slotGroup)
+	var groups []*slotGroup
+	for _, key := range keys {
+		fullKey := r.config.KeyPrefix + key
+		slot := keySlot(fullKey)
+		g, ok := bySlot[slot]
+		if !ok {
+			g = &slotGroup{}
+			bySlot[slot] = g
+			groups = append(groups, g)
+		}
+		g.keys = append(g.keys, key)
+		g.fullKeys = append(g.fullKeys, fullKey)
+	}
+	return groups
+}
+
+// GetMulti retrieves several keys, with one MGET per hash slot sent in a
+// single pipeline. With client-side caching, keys are read with cacheable GETs
+// instead. Missing keys are absent from the result. Keys that fail (slot
+// unreachable, undecodable value) are reported in a *cache.BatchError returned
+// along with the keys that succeeded.
+func (r *RedisCache) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	entries, err := r.getMulti(ctx, keys, false)
+	results := make(map[string]interface{}, len(entries))
+	for key, entry := range entries {
+		results[key] = entry.Value
+	}
+	return results, err
+}
+
+// GetMultiEntries retrieves several entries like GetMulti, with the metadata
+// GetEntry returns. For values written with Set, ExpiresAt comes from the
+// keys' PTTL, pipelined with the MGETs.
+func (r *RedisCache) GetMultiEntries(ctx context.Context, keys []string) (map[string]*cache.CacheEntry, error) {
+	return r.getMulti(ctx, keys, true)
+}
+
+// getMulti reads keys for GetMulti and GetMultiEntries. With withTTL, the
+// expiry of keys without metadata is read as well.
+func (r *RedisCache) getMulti(ctx context.Context, keys []string, withTTL bool) (map[string]*cache.CacheEntry, error) {
+	results := make(map[string]*cache.CacheEntry, len(keys))
+	if len(keys) == 0 {
+		return results, nil
+	}
+
+	var batchErr cache.BatchError
+	if r.config.ClientSideCacheTTL > 0 {
+		cmds := make([]rueidis.CacheableTTL, len(keys))
+		for i, key := range keys {
+			fullKey := r.config.KeyPrefix + key
+			cmds[i] = rueidis.CT(r.client.B().Get().Key(fullKey).Cache(), r.config.ClientSideCacheTTL)
+		}
+
+		for i, resp := range r.client.DoMultiCache(ctx, cmds...) {
+			r.recordClientCache(resp)
+			var expiresAt time.Time
+			if pxat := resp.CachePXAT(); withTTL && pxat > 0 {
+				expiresAt = time.UnixMilli(pxat)
+			}
+			r.collect(results, &batchErr, keys[i], expiresAt, resp.ToMessage)
+		}
+	} else {
+		groups := r.groupBySlot(keys)
+		cmds := make([]rueidis.Completed, 0, len(groups)+len(keys))
+		for _, g := range groups {
+			cmds = append(cmds, r.client.B().Mget().Key(g.fullKeys...).Build())
+		}
+		if withTTL {
+			// PTTLs ride in the same pipeline, after the MGETs
+			for _, g := range groups {
+				for _, fullKey := range g.fullKeys {
+					cmds = append(cmds, r.client.B().Pttl().Key(fullKey).Build())
+				}
+			}
+		}
+
+		resps := r.client.DoMulti(ctx, cmds...)
+		pttls := resps[len(groups):]
+		for i, g := range groups {
+			// PTTL replies of this group's keys, if requested
+			var ttls []rueidis.RedisResult
+			if withTTL {
+				ttls, pttls = pttls[:len(g.keys)], pttls[len(g.keys):]
+			}
+
+			values, err := resps[i].ToArray()
+			if err == nil && len(values) != len(g.keys) {
+				err = fmt.Errorf("MGET returned %d values for %d keys", len(values), len(g.keys))
+			}
+			if err != nil {
+				for _, key := range g.keys {
+					batchErr.Add(key, fmt.Errorf("redis get multi: %w", err))
+				}
+				continue
+			}
+
+			for j, key := range g.keys {
+				var expiresAt time.Time
+				if withTTL {
+					if ms, err := ttls[j].AsInt64(); err == nil && ms > 0 {
+						expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+					}
+				}
+				msg := values[j]
+				r.collect(results, &batchErr, key, expiresAt, func() (rueidis.RedisMessage, error) {
+					return msg, msg.Error()
+				})
+			}
+		}
+	}
+
+	if err := batchErr.Err(); err != nil {
+		r.logger.Warn("batch get partially failed",
+			zap.Int("keys", len(keys)),
+			zap.Int("failed", len(batchErr.Errors)),
+			zap.Error(err),
+		)
+		return results, err
+	}
+
+	r.logger.Debug("batch get completed",
+		zap.Int("keys", len(keys)),
+		zap.Int("found", len(results)),
+	)
+	return results, nil
+}
+
+// collect decodes one reply into results, recording failures in batchErr.
+// expiresAt is used for entries stored without metadata.
+func (r *RedisCache) collect(results map[string]*cache.CacheEntry, batchErr *cache.BatchError, key string, expiresAt time.Time, reply func() (rueidis.RedisMessage, error)) {
+	msg, err := reply()
+	if err != nil {
+		if !rueidis.IsRedisNil(err) {
+			batchErr.Add(key, fmt.Errorf("redis get multi: %w", err))
+		}
+		return
+	}
+	if msg.IsNil() {
+		return
+	}
+
+	data, err := msg.AsBytes()
+	if err != nil {
+		batchErr.Add(key, fmt.Errorf("redis get multi: failed to read response: %w", err))
+		return
+	}
+
+	entry := &cache.CacheEntry{Key: key}
+	if _, err := cache.DecodeEntry(r.config.Codec, data, entry); err != nil {
+		batchErr.Add(key, fmt.Errorf("redis get multi: failed to unmarshal: %w", err))
+		return
+	}
+	if entry.ExpiresAt.IsZero() {
+		entry.ExpiresAt = expiresAt
+	}
+	results[key] = entry
+}
+
+// SetMulti stores several values with the same TTL in a single pipeline,
+// expiring like Set. Each SET is routed to its key's node in cluster mode.
+// Keys that fail are reported in a *cache.BatchError; the others are stored.
+func (r *RedisCache) SetMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var batchErr cache.BatchError
+	cmds := make([]rueidis.Completed, 0, len(items))
+	keys := make([]string, 0, len(items))
+
+	for key, value := range items {
+		data, err := r.encode(value)
+		if err != nil {
+			batchErr.Add(key, fmt.Errorf("redis set multi: failed to marshal: %w", err))
+			continue
+		}
+
+		fullKey := r.config.KeyPrefix + key
+		keys = append(keys, key)
+		cmds = append(cmds, r.setCmd(fullKey, data, ttl))
+	}
+
+	if len(cmds) > 0 {
+		for i, resp := range r.client.DoMulti(ctx, cmds...) {
+			if err := resp.Error(); err != nil {
+				batchErr.Add(keys[i], fmt.Errorf("redis set multi: %w", err))
+			}
+		}
+	}
+
+	if err := batchErr.Err(); err != nil {
+		r.logger.Warn("batch set partially failed",
+			zap.Int("keys", len(items)),
+			zap.Int("failed", len(batchErr.Errors)),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	r.logger.Debug("batch set completed",
+		zap.Int("keys", len(items)),
+		zap.Duration("ttl", ttl),
+	)
+	return nil
+}
+
+// DeleteMulti removes several keys, with one DEL per hash slot sent in a
+// single pipeline. Keys of slots that fail are reported in a *cache.BatchError.
+func (r *RedisCache) DeleteMulti(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	groups := r.groupBySlot(keys)
+	cmds := make([]rueidis.Completed, len(groups))
+	for i, g := range groups {
+		cmds[i] = r.client.B().Del().Key(g.fullKeys...).Build()
+	}
+
+	var batchErr cache.BatchError
+	for i, resp := range r.client.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			for _, key := range groups[i].keys {
+				batchErr.Add(key, fmt.Errorf("redis delete multi: %w", err))
+			}
+		}
+	}
+
+	if err := batchErr.Err(); err != nil {
+		r.logger.Warn("batch delete partially failed",
+			zap.Int("keys", len(keys)),
+			zap.Int("failed", len(batchErr.Errors)),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	r.logger.Debug("batch delete completed",
+		zap.Int("keys", len(keys)),
+	)
+	return nil
+}
@@ -0,0 +1,184 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cache-chain/pkg/logging"
+
+	"github.com/redis/rueidis"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidationBusClosed is returned when using a closed invalidation bus.
+var ErrInvalidationBusClosed = errors.New("redis: invalidation bus closed")
+
+// InvalidationBus broadcasts key invalidations over Redis pub/sub.
+// It satisfies chain.InvalidationBus and shares the RedisCache client,
+// so no extra connection configuration is needed.
+type InvalidationBus struct {
+	client  rueidis.Client
+	channel string
+	logger  *logging.Logger
+
+	mu       sync.RWMutex
+	handlers map[int]func(origin string, keys []string)
+	nextID   int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// invalidationMessage is the payload published on the channel.
+type invalidationMessage struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// NewInvalidationBus creates an invalidation bus on the RedisCache client.
+// If channel is empty, KeyPrefix + "invalidations" is used so separate
+// namespaces don't invalidate each other.
+// The bus subscribes immediately and must be closed with Close().
+func (r *RedisCache) NewInvalidationBus(channel string) *InvalidationBus {
+	if channel == "" {
+		channel = r.config.KeyPrefix + "invalidations"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &InvalidationBus{
+		client:   r.client,
+		channel:  channel,
+		logger:   r.logger.Named("invalidation"),
+		handlers: make(map[int]func(origin string, keys []string)),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go b.receive()
+
+	b.logger.Info("invalidation bus started",
+		zap.String("channel", channel),
+	)
+
+	return b
+}
+
+// Publish broadcasts that keys were changed by origin.
+func (b *InvalidationBus) Publish(ctx context.Context, origin string, keys ...string) error {
+	if b.ctx.Err() != nil {
+		return ErrInvalidationBusClosed
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(invalidationMessage{Origin: origin, Keys: keys})
+	if err != nil {
+		return fmt.Errorf("redis publish: failed to marshal: %w", err)
+	}
+
+	cmd := b.client.B().Publish().Channel(b.channel).Message(string(payload)).Build()
+	if err := b.client.Do(ctx, cmd).Error(); err != nil {
+		return fmt.Errorf("redis publish: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe registers a handler for all invalidations on the channel.
+func (b *InvalidationBus) Subscribe(handler func(origin string, keys []string)) (func(), error) {
+	if b.ctx.Err() != nil {
+		return nil, ErrInvalidationBusClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+// Close stops the subscription.
+func (b *InvalidationBus) Close() error {
+	b.cancel()
+	<-b.done
+	return nil
+}
+
+// receive keeps the channel subscription alive until Close, resubscribing
+// with backoff when the connection drops.
+func (b *InvalidationBus) receive() {
+	defer close(b.done)
+
+	backoff := 100 * time.Millisecond
+	const maxBackoff = 5 * time.Second
+
+	for {
+		start := time.Now()
+		cmd := b.client.B().Subscribe().Channel(b.channel).Build()
+		err := b.client.Receive(b.ctx, cmd, b.dispatch)
+
+		if b.ctx.Err() != nil {
+			return
+		}
+
+		// A subscription that was healthy for a while starts over
+		if time.Since(start) > maxBackoff {
+			backoff = 100 * time.Millisecond
+		}
+
+		b.logger.Warn("invalidation subscription lost - retrying",
+			zap.String("channel", b.channel),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-b.ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// dispatch decodes a pub/sub message and fans it out to handlers.
+func (b *InvalidationBus) dispatch(msg rueidis.PubSubMessage) {
+	var m invalidationMessage
+	if err := json.Unmarshal([]byte(msg.Message), &m); err != nil {
+		b.logger.Warn("malformed invalidation message",
+			zap.String("channel", msg.Channel),
+			zap.Error(err),
+		)
+		return
+	}
+
+	b.mu.RLock()
+	handlers := make([]func(origin string, keys []string), 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(m.Origin, m.Keys)
+	}
+}
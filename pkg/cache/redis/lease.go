@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cache-chain/pkg/cache"
+
+	"github.com/redis/rueidis"
+	"go.uber.org/zap"
+)
+
+// acquireScript sets the lease key with SET NX PX, storing a fencing token
+// taken from the server clock in microseconds, so tokens grow from one holder
+// to the next without any state outliving the lease. Returns the token, or 0
+// if the lease is held.
+var acquireScript = rueidis.NewLuaScript(`
+local now = redis.call('TIME')
+local token = now[1] .. string.format('%06d', now[2])
+if redis.call('SET', KEYS[1], token, 'NX', 'PX', ARGV[1]) then
+	return token
+end
+return 0
+`)
+
+// releaseScript deletes the lease key only if it still holds the token.
+var releaseScript = rueidis.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// fencedSetScript stores a value only if the lease key still holds the
+// token. A TTL of 0 stores it without expiry.
+var fencedSetScript = rueidis.NewLuaScript(`
+if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[3] == '0' then
+	redis.call('SET', KEYS[1], ARGV[2])
+else
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+end
+return 1
+`)
+
+// Lease is a distributed lock over Redis SET NX PX. It satisfies chain.Lease
+// and shares the RedisCache client. The lease key of a cache key lives in the
+// same cluster slot as the cached value, so the RedisCache can check the
+// lease and write the value in one script (see SetEntryFenced).
+type Lease struct {
+	client    rueidis.Client
+	prefix    string
+	keyPrefix string
+}
+
+// NewLease creates a lease on the RedisCache client.
+// If prefix is empty, KeyPrefix + "lease:" is used.
+func (r *RedisCache) NewLease(prefix string) *Lease {
+	if prefix == "" {
+		prefix = r.config.KeyPrefix + "lease:"
+	}
+	return &Lease{
+		client:    r.client,
+		prefix:    prefix,
+		keyPrefix: r.config.KeyPrefix,
+	}
+}
+
+// Acquire takes the lease on key for ttl unless another holder has it.
+func (l *Lease) Acquire(ctx context.Context, key string, ttl time.Duration) (uint64, bool, error) {
+	args := []string{strconv.FormatInt(ttl.Milliseconds(), 10)}
+
+	token, err := acquireScript.Exec(ctx, l.client, []string{l.FenceKey(key)}, args).AsInt64()
+	if err != nil {
+		return 0, false, fmt.Errorf("redis lease acquire: %w", err)
+	}
+	if token == 0 {
+		return 0, false, nil
+	}
+	return uint64(token), true, nil
+}
+
+// Release deletes the lease if token still holds it.
+func (l *Lease) Release(ctx context.Context, key string, token uint64) error {
+	args := []string{strconv.FormatUint(token, 10)}
+
+	if err := releaseScript.Exec(ctx, l.client, []string{l.FenceKey(key)}, args).Error(); err != nil {
+		return fmt.Errorf("redis lease release: %w", err)
+	}
+	return nil
+}
+
+// Holds reports whether token still holds the lease on key.
+func (l *Lease) Holds(ctx context.Context, key string, token uint64) (bool, error) {
+	cmd := l.client.B().Get().Key(l.FenceKey(key)).Build()
+	current, err := l.client.Do(ctx, cmd).ToString()
+	if rueidis.IsRedisNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("redis lease holds: %w", err)
+	}
+	return current == strconv.FormatUint(token, 10), nil
+}
+
+// FenceKey returns the Redis key holding the lease on key. It is hash-tagged
+// to the cluster slot of the cached value's key.
+func (l *Lease) FenceKey(key string) string {
+	return "{" + slotTags()[keySlot(l.keyPrefix+key)] + "}" + l.prefix + key
+}
+
+// SetEntryFenced stores entry like SetEntry, in the same script that checks
+// the lease at fenceKey (see Lease.FenceKey) still holds token, and reports
+// whether it was stored. A holder whose lease expired can't overwrite the
+// value of the next holder.
+func (r *RedisCache) SetEntryFenced(ctx context.Context, entry *cache.CacheEntry, fenceKey string, token uint64) (bool, error) {
+	var ttl time.Duration
+	if !entry.ExpiresAt.IsZero() {
+		ttl = time.Until(entry.ExpiresAt)
+		if ttl <= 0 {
+			// Already expired: nothing worth storing
+			return false, nil
+		}
+		if ttl < time.Millisecond {
+			ttl = time.Millisecond
+		}
+	}
+
+	data, stats, err := cache.EncodeEntry(r.config.Codec, r.config.Compression, entry)
+	if err != nil {
+		return false, fmt.Errorf("redis set: failed to marshal: %w", err)
+	}
+	if stats.Compression != cache.CompressionNone {
+		r.metrics.RecordCompression(r.name, stats.RawSize, stats.StoredSize)
+	}
+
+	keys := []string{r.config.KeyPrefix + entry.Key, fenceKey}
+	args := []string{
+		strconv.FormatUint(token, 10),
+		string(data),
+		strconv.FormatInt(ttl.Milliseconds(), 10),
+	}
+	stored, err := fencedSetScript.Exec(ctx, r.client, keys, args).AsInt64()
+	if err != nil {
+		r.logger.Error("redis fenced set error",
+			zap.String("key", entry.Key),
+			zap.Duration("ttl", ttl),
+			zap.Error(err),
+		)
+		return false, fmt.Errorf("redis set: %w", err)
+	}
+
+	r.logger.Debug("cache set entry fenced",
+		zap.String("key", entry.Key),
+		zap.Duration("ttl", ttl),
+		zap.Bool("stored", stored == 1),
+	)
+	return stored == 1, nil
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cache-chain/pkg/cache"
+
+	"github.com/redis/rueidis"
+	"go.uber.org/zap"
+)
+
+// scanCount is the COUNT hint of each SCAN call.
+const scanCount = 1000
+
+// DeletePrefix removes every key starting with prefix and returns how many
+// were removed. Keys are found with SCAN cursors on every node (each cluster
+// node, or the single server), so Redis is never blocked by a full keyspace
+// listing, and each page is removed with UNLINK grouped by hash slot.
+// onBatch (if not nil) receives the keys of each page once removed.
+// An empty prefix is rejected.
+func (r *RedisCache) DeletePrefix(ctx context.Context, prefix string, onBatch func(keys []string)) (int, error) {
+	if prefix == "" {
+		return 0, cache.ErrInvalidKey
+	}
+
+	deleted := 0
+	err := r.scan(ctx, escapePattern(r.config.KeyPrefix+prefix)+"*", func(keys []string) error {
+		n, err := r.unlink(ctx, keys)
+		deleted += n
+		if err != nil {
+			return err
+		}
+		if onBatch != nil {
+			onBatch(keys)
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("redis delete prefix error",
+			zap.String("prefix", prefix),
+			zap.Int("deleted", deleted),
+			zap.Error(err),
+		)
+		return deleted, fmt.Errorf("redis delete prefix: %w", err)
+	}
+
+	r.logger.Debug("prefix deleted",
+		zap.String("prefix", prefix),
+		zap.Int("keys", deleted),
+	)
+
+	return deleted, nil
+}
+
+// scan calls fn with each page of keys matching the full pattern, on every
+// node the client knows, with KeyPrefix removed. A key may be seen more than
+// once if replicas are scanned too. fn returning an error stops the scan.
+func (r *RedisCache) scan(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	for addr, node := range r.client.Nodes() {
+		var cursor uint64
+		for {
+			cmd := node.B().Scan().Cursor(cursor).Match(pattern).Count(scanCount).Build()
+			entry, err := node.Do(ctx, cmd).AsScanEntry()
+			if err != nil {
+				return fmt.Errorf("scan %s: %w", addr, err)
+			}
+
+			if len(entry.Elements) > 0 {
+				keys := make([]string, len(entry.Elements))
+				for i, fullKey := range entry.Elements {
+					keys[i] = strings.TrimPrefix(fullKey, r.config.KeyPrefix)
+				}
+				if err := fn(keys); err != nil {
+					return err
+				}
+			}
+
+			cursor = entry.Cursor
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// unlink removes keys with one UNLINK per hash slot, pipelined, and returns
+// how many existed.
+func (r *RedisCache) unlink(ctx context.Context, keys []string) (int, error) {
+	groups := r.groupBySlot(keys)
+	cmds := make(rueidis.Commands, len(groups))
+	for i, g := range groups {
+		cmds[i] = r.client.B().Unlink().Key(g.fullKeys...).Build()
+	}
+
+	removed := 0
+	for _, resp := range r.client.DoMulti(ctx, cmds...) {
+		n, err := resp.AsInt64()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+	return removed, nil
+}
+
+// escapePattern escapes the glob characters of s for MATCH patterns.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -2,23 +2,32 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"cache-chain/pkg/cache"
 	"cache-chain/pkg/logging"
+	"cache-chain/pkg/metrics"
 
 	"github.com/redis/rueidis"
 	"go.uber.org/zap"
 )
 
 type RedisCache struct {
-	client rueidis.Client
-	name   string
-	config RedisCacheConfig
-	logger *logging.Logger
+	client  rueidis.Client
+	name    string
+	config  RedisCacheConfig
+	metrics metrics.MetricsCollector
+	logger  *logging.Logger
+
+	// cluster is set when the client talks to a Redis Cluster, which the
+	// client detects even from a single seed address
+	cluster bool
+
+	// Client-side cache statistics (accessed atomically)
+	clientCacheHits   int64
+	clientCacheMisses int64
 }
 
 type RedisCacheConfig struct {
@@ -51,6 +60,26 @@ type RedisCacheConfig struct {
 	SentinelAddrs    []string
 	SentinelUsername string
 	SentinelPassword string
+	// Codec serializes values stored in Redis (optional, defaults to cache.JSONCodec).
+	// Every stored value carries a header naming its codec, so values written
+	// with a previous codec remain readable after switching.
+	Codec cache.Codec
+	// Compression compresses values above a size threshold (optional, disabled by default).
+	// The algorithm is recorded per value, so changing it doesn't affect existing keys.
+	Compression cache.CompressionConfig
+	// ClientSideCacheTTL enables server-assisted client-side caching (RESP3 tracking)
+	// for Get and BatchGet. Values are kept in a local cache for up to this duration
+	// and Redis pushes invalidations when keys change, giving coherent near-cache
+	// behavior without a separate memory layer. 0 disables it (default).
+	ClientSideCacheTTL time.Duration
+	// ClientSideCacheSize is the local cache size in bytes per connection
+	// (optional, defaults to the rueidis default of 128 MiB)
+	ClientSideCacheSize int
+	// TagPrefix prefixes the sets indexing keys by tag for SetWithTags and
+	// InvalidateTag (optional, defaults to KeyPrefix + "tag:")
+	TagPrefix string
+	// Metrics collector for compression statistics (optional, defaults to NoOpCollector)
+	Metrics metrics.MetricsCollector
 	// Logger for structured logging (optional, uses global if nil)
 	Logger *logging.Logger
 }
@@ -70,6 +99,7 @@ func DefaultRedisCacheConfig() RedisCacheConfig {
 		PoolSize:         10,
 		MinIdleConns:     2,
 		EnablePipelining: true,
+		Codec:            cache.JSONCodec{},
 	}
 }
 
@@ -102,6 +132,15 @@ func NewRedisCache(config RedisCacheConfig) (*RedisCache, error) {
 	if config.Name == "" {
 		config.Name = "Redis"
 	}
+	if config.Codec == nil {
+		config.Codec = cache.JSONCodec{}
+	}
+	if config.Metrics == nil {
+		config.Metrics = metrics.NoOpCollector{}
+	}
+	if config.TagPrefix == "" {
+		config.TagPrefix = config.KeyPrefix + "tag:"
+	}
 
 	// Determine addresses based on configuration
 	var initAddress []string
@@ -127,6 +166,10 @@ func NewRedisCache(config RedisCacheConfig) (*RedisCache, error) {
 		MaxFlushDelay:    100 * time.Microsecond,
 	}
 
+	if config.ClientSideCacheSize > 0 {
+		clientOpts.CacheSizeEachConn = config.ClientSideCacheSize
+	}
+
 	// Configure Sentinel if enabled
 	if len(config.SentinelAddrs) > 0 {
 		clientOpts.Sentinel = rueidis.SentinelOption{
@@ -156,17 +199,22 @@ func NewRedisCache(config RedisCacheConfig) (*RedisCache, error) {
 	}
 
 	redisCache := &RedisCache{
-		client: client,
-		name:   config.Name,
-		config: config,
-		logger: logger.Named(config.Name),
+		client:  client,
+		name:    config.Name,
+		config:  config,
+		metrics: config.Metrics,
+		logger:  logger.Named(config.Name),
+		cluster: client.Mode() == rueidis.ClientModeCluster,
 	}
 
 	redisCache.logger.Info("redis cache initialized",
 		zap.String("name", config.Name),
 		zap.Strings("addresses", initAddress),
 		zap.String("key_prefix", config.KeyPrefix),
-		zap.Bool("cluster_mode", len(config.ClusterAddrs) > 0),
+		zap.String("codec", config.Codec.ContentType()),
+		zap.String("compression", config.Compression.Algorithm.String()),
+		zap.Duration("client_side_cache_ttl", config.ClientSideCacheTTL),
+		zap.Bool("cluster_mode", redisCache.cluster),
 		zap.Bool("sentinel_mode", len(config.SentinelAddrs) > 0),
 	)
 
@@ -174,10 +222,89 @@ func NewRedisCache(config RedisCacheConfig) (*RedisCache, error) {
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (interface{}, error) {
+	data, _, err := r.fetch(ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := cache.Decode(r.config.Codec, data, &value); err != nil {
+		r.logger.Error("failed to unmarshal",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("redis get: failed to unmarshal: %w", err)
+	}
+
+	r.logger.Debug("cache hit",
+		zap.String("key", key),
+		zap.Int("value_size", len(data)),
+	)
+
+	return value, nil
+}
+
+// GetEntry retrieves a value with the metadata stored by SetEntry.
+// For values written with Set, ExpiresAt comes from the key's PTTL, fetched
+// in the same round trip. With client-side caching it is the cached copy's
+// expiry, which never exceeds the key's.
+func (r *RedisCache) GetEntry(ctx context.Context, key string) (*cache.CacheEntry, error) {
+	data, expiresAt, err := r.fetch(ctx, key, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cache.CacheEntry{Key: key}
+	if _, err := cache.DecodeEntry(r.config.Codec, data, entry); err != nil {
+		r.logger.Error("failed to unmarshal",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("redis get: failed to unmarshal: %w", err)
+	}
+	if entry.ExpiresAt.IsZero() {
+		entry.ExpiresAt = expiresAt
+	}
+
+	r.logger.Debug("cache hit",
+		zap.String("key", key),
+		zap.Int("value_size", len(data)),
+		zap.Time("stale_at", entry.StaleAt),
+		zap.Time("expires_at", entry.ExpiresAt),
+	)
+
+	return entry, nil
+}
+
+// fetch reads the raw stored bytes for key, through the client-side cache when enabled.
+// With withTTL it also returns the key's expiry (zero if it has none).
+func (r *RedisCache) fetch(ctx context.Context, key string, withTTL bool) ([]byte, time.Time, error) {
 	fullKey := r.config.KeyPrefix + key
 
-	cmd := r.client.B().Get().Key(fullKey).Build()
-	resp := r.client.Do(ctx, cmd)
+	var resp rueidis.RedisResult
+	var expiresAt time.Time
+	switch {
+	case r.config.ClientSideCacheTTL > 0:
+		cmd := r.client.B().Get().Key(fullKey).Cache()
+		resp = r.client.DoCache(ctx, cmd, r.config.ClientSideCacheTTL)
+		r.recordClientCache(resp)
+		if pxat := resp.CachePXAT(); withTTL && pxat > 0 {
+			expiresAt = time.UnixMilli(pxat)
+		}
+	case withTTL:
+		// GET and PTTL pipelined in one round trip
+		results := r.client.DoMulti(ctx,
+			r.client.B().Get().Key(fullKey).Build(),
+			r.client.B().Pttl().Key(fullKey).Build(),
+		)
+		resp = results[0]
+		if ms, err := results[1].AsInt64(); err == nil && ms > 0 {
+			expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+	default:
+		cmd := r.client.B().Get().Key(fullKey).Build()
+		resp = r.client.Do(ctx, cmd)
+	}
 
 	if err := resp.Error(); err != nil {
 		if rueidis.IsRedisNil(err) {
@@ -185,13 +312,13 @@ func (r *RedisCache) Get(ctx context.Context, key string) (interface{}, error) {
 				zap.String("key", key),
 				zap.String("full_key", fullKey),
 			)
-			return nil, cache.ErrCacheMiss
+			return nil, time.Time{}, cache.ErrCacheMiss
 		}
 		r.logger.Error("redis get error",
 			zap.String("key", key),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("redis get: %w", err)
+		return nil, time.Time{}, fmt.Errorf("redis get: %w", err)
 	}
 
 	data, err := resp.AsBytes()
@@ -200,57 +327,120 @@ func (r *RedisCache) Get(ctx context.Context, key string) (interface{}, error) {
 			zap.String("key", key),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("redis get: failed to read response: %w", err)
+		return nil, time.Time{}, fmt.Errorf("redis get: failed to read response: %w", err)
 	}
 
-	var value interface{}
-	if err := json.Unmarshal(data, &value); err != nil {
-		r.logger.Error("failed to unmarshal",
+	return data, expiresAt, nil
+}
+
+// Set stores a value expiring after ttl, with millisecond precision.
+// A ttl <= 0 stores it without expiry.
+func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	fullKey := r.config.KeyPrefix + key
+
+	data, err := r.encode(value)
+	if err != nil {
+		r.logger.Error("failed to marshal",
 			zap.String("key", key),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("redis get: failed to unmarshal: %w", err)
+		return fmt.Errorf("redis set: failed to marshal: %w", err)
 	}
 
-	r.logger.Debug("cache hit",
+	cmd := r.setCmd(fullKey, data, ttl)
+	if err := r.client.Do(ctx, cmd).Error(); err != nil {
+		r.logger.Error("redis set error",
+			zap.String("key", key),
+			zap.Duration("ttl", ttl),
+			zap.Error(err),
+		)
+		return fmt.Errorf("redis set: %w", err)
+	}
+
+	r.logger.Debug("cache set",
 		zap.String("key", key),
+		zap.Duration("ttl", ttl),
 		zap.Int("value_size", len(data)),
 	)
 
-	return value, nil
+	return nil
 }
 
-func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	fullKey := r.config.KeyPrefix + key
+// SetEntry stores a value with its metadata, which is kept in the value
+// header. The key expires at entry.ExpiresAt, or never if it's zero.
+func (r *RedisCache) SetEntry(ctx context.Context, entry *cache.CacheEntry) error {
+	fullKey := r.config.KeyPrefix + entry.Key
 
-	data, err := json.Marshal(value)
+	data, stats, err := cache.EncodeEntry(r.config.Codec, r.config.Compression, entry)
 	if err != nil {
 		r.logger.Error("failed to marshal",
-			zap.String("key", key),
+			zap.String("key", entry.Key),
 			zap.Error(err),
 		)
 		return fmt.Errorf("redis set: failed to marshal: %w", err)
 	}
+	if stats.Compression != cache.CompressionNone {
+		r.metrics.RecordCompression(r.name, stats.RawSize, stats.StoredSize)
+	}
+
+	var ttl time.Duration
+	if !entry.ExpiresAt.IsZero() {
+		ttl = time.Until(entry.ExpiresAt)
+		if ttl <= 0 {
+			// Already expired: make sure no older value lingers
+			return r.Delete(ctx, entry.Key)
+		}
+	}
+	cmd := r.setCmd(fullKey, data, ttl)
 
-	cmd := r.client.B().Set().Key(fullKey).Value(string(data)).Ex(ttl).Build()
 	if err := r.client.Do(ctx, cmd).Error(); err != nil {
 		r.logger.Error("redis set error",
-			zap.String("key", key),
+			zap.String("key", entry.Key),
 			zap.Duration("ttl", ttl),
 			zap.Error(err),
 		)
 		return fmt.Errorf("redis set: %w", err)
 	}
 
-	r.logger.Debug("cache set",
-		zap.String("key", key),
+	r.logger.Debug("cache set entry",
+		zap.String("key", entry.Key),
 		zap.Duration("ttl", ttl),
+		zap.Time("stale_at", entry.StaleAt),
 		zap.Int("value_size", len(data)),
 	)
 
 	return nil
 }
 
+// setCmd builds the SET of data at fullKey expiring after ttl, with
+// millisecond precision (shorter TTLs are rounded up to 1ms). A ttl <= 0
+// stores the value without expiry.
+func (r *RedisCache) setCmd(fullKey string, data []byte, ttl time.Duration) rueidis.Completed {
+	set := r.client.B().Set().Key(fullKey).Value(rueidis.BinaryString(data))
+	if ttl <= 0 {
+		return set.Build()
+	}
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+	return set.Px(ttl).Build()
+}
+
+// encode serializes a value with the configured codec and compression,
+// reporting compression statistics when the value was compressed.
+func (r *RedisCache) encode(value interface{}) ([]byte, error) {
+	data, stats, err := cache.EncodeCompressed(r.config.Codec, r.config.Compression, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Compression != cache.CompressionNone {
+		r.metrics.RecordCompression(r.name, stats.RawSize, stats.StoredSize)
+	}
+
+	return data, nil
+}
+
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	fullKey := r.config.KeyPrefix + key
 
@@ -285,105 +475,61 @@ func (r *RedisCache) Close() error {
 	return nil
 }
 
+// BatchGet retrieves several keys.
+//
+// Deprecated: use GetMulti, which satisfies cache.BatchCacheLayer.
 func (r *RedisCache) BatchGet(ctx context.Context, keys []string) (map[string]interface{}, error) {
-	if len(keys) == 0 {
-		return make(map[string]interface{}), nil
-	}
-
-	cmds := make([]rueidis.Completed, len(keys))
-	for i, key := range keys {
-		fullKey := r.config.KeyPrefix + key
-		cmds[i] = r.client.B().Get().Key(fullKey).Build()
-	}
-
-	results := r.client.DoMulti(ctx, cmds...)
-
-	resultMap := make(map[string]interface{}, len(keys))
-	var errs []error
-
-	for i, resp := range results {
-		if err := resp.Error(); err != nil {
-			if !rueidis.IsRedisNil(err) {
-				errs = append(errs, fmt.Errorf("key %s: %w", keys[i], err))
-			}
-			continue
-		}
-
-		data, err := resp.AsBytes()
-		if err != nil {
-			errs = append(errs, fmt.Errorf("key %s: failed to read: %w", keys[i], err))
-			continue
-		}
-
-		var value interface{}
-		if err := json.Unmarshal(data, &value); err != nil {
-			errs = append(errs, fmt.Errorf("key %s: failed to unmarshal: %w", keys[i], err))
-			continue
-		}
-
-		resultMap[keys[i]] = value
-	}
-
-	if len(errs) > 0 {
-		return resultMap, errors.Join(errs...)
-	}
-
-	return resultMap, nil
+	return r.GetMulti(ctx, keys)
 }
 
+// BatchSet stores several values with the same TTL.
+//
+// Deprecated: use SetMulti, which satisfies cache.BatchCacheLayer.
 func (r *RedisCache) BatchSet(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
-	if len(items) == 0 {
-		return nil
-	}
-
-	cmds := make([]rueidis.Completed, 0, len(items))
-	keys := make([]string, 0, len(items))
-
-	for key, value := range items {
-		fullKey := r.config.KeyPrefix + key
-		keys = append(keys, key)
-
-		data, err := json.Marshal(value)
-		if err != nil {
-			return fmt.Errorf("redis batch set: key %s: failed to marshal: %w", key, err)
-		}
-
-		cmd := r.client.B().Set().Key(fullKey).Value(string(data)).Ex(ttl).Build()
-		cmds = append(cmds, cmd)
-	}
+	return r.SetMulti(ctx, items, ttl)
+}
 
-	results := r.client.DoMulti(ctx, cmds...)
+// BatchDelete removes several keys.
+//
+// Deprecated: use DeleteMulti, which satisfies cache.BatchCacheLayer.
+func (r *RedisCache) BatchDelete(ctx context.Context, keys []string) error {
+	return r.DeleteMulti(ctx, keys)
+}
 
-	var errs []error
-	for i, resp := range results {
-		if err := resp.Error(); err != nil {
-			errs = append(errs, fmt.Errorf("key %s: %w", keys[i], err))
-		}
+// recordClientCache counts whether a cacheable read was served locally.
+// Transport errors are ignored since they never reach the local cache.
+func (r *RedisCache) recordClientCache(resp rueidis.RedisResult) {
+	if err := resp.Error(); err != nil && !rueidis.IsRedisNil(err) {
+		return
 	}
-
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	if resp.IsCacheHit() {
+		atomic.AddInt64(&r.clientCacheHits, 1)
+	} else {
+		atomic.AddInt64(&r.clientCacheMisses, 1)
 	}
-
-	return nil
 }
 
-func (r *RedisCache) BatchDelete(ctx context.Context, keys []string) error {
-	if len(keys) == 0 {
-		return nil
+// Stats returns current cache statistics.
+func (r *RedisCache) Stats() RedisCacheStats {
+	stats := RedisCacheStats{
+		ClientSideCacheEnabled: r.config.ClientSideCacheTTL > 0,
+		ClientCacheHits:        atomic.LoadInt64(&r.clientCacheHits),
+		ClientCacheMisses:      atomic.LoadInt64(&r.clientCacheMisses),
 	}
 
-	fullKeys := make([]string, len(keys))
-	for i, key := range keys {
-		fullKeys[i] = r.config.KeyPrefix + key
+	if total := stats.ClientCacheHits + stats.ClientCacheMisses; total > 0 {
+		stats.ClientCacheHitRatio = float64(stats.ClientCacheHits) / float64(total)
 	}
 
-	cmd := r.client.B().Del().Key(fullKeys...).Build()
-	if err := r.client.Do(ctx, cmd).Error(); err != nil {
-		return fmt.Errorf("redis batch delete: %w", err)
-	}
+	return stats
+}
 
-	return nil
+// RedisCacheStats holds cache statistics.
+type RedisCacheStats struct {
+	ClientSideCacheEnabled bool    // Whether client-side caching is enabled
+	ClientCacheHits        int64   // Reads served from the local client-side cache
+	ClientCacheMisses      int64   // Reads that went to the Redis server
+	ClientCacheHitRatio    float64 // ClientCacheHits / (ClientCacheHits + ClientCacheMisses)
 }
 
 func (r *RedisCache) Ping(ctx context.Context) error {
@@ -402,29 +548,24 @@ func (r *RedisCache) FlushDB(ctx context.Context) error {
 	return nil
 }
 
+// Keys returns the keys matching the glob pattern, without KeyPrefix.
+// It iterates with SCAN on every node instead of KEYS, so it works in
+// cluster mode and doesn't block the server, but it still loads every match
+// in memory; use DeletePrefix to invalidate large keyspaces.
 func (r *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
-	fullPattern := r.config.KeyPrefix + pattern
-
-	cmd := r.client.B().Keys().Pattern(fullPattern).Build()
-	resp := r.client.Do(ctx, cmd)
-
-	if err := resp.Error(); err != nil {
-		return nil, fmt.Errorf("redis keys: %w", err)
-	}
-
-	keys, err := resp.AsStrSlice()
-	if err != nil {
-		return nil, fmt.Errorf("redis keys: failed to read response: %w", err)
-	}
-
-	prefixLen := len(r.config.KeyPrefix)
-	result := make([]string, len(keys))
-	for i, key := range keys {
-		if len(key) >= prefixLen {
-			result[i] = key[prefixLen:]
-		} else {
-			result[i] = key
+	seen := make(map[string]struct{})
+	result := []string{}
+	err := r.scan(ctx, r.config.KeyPrefix+pattern, func(keys []string) error {
+		for _, key := range keys {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				result = append(result, key)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis keys: %w", err)
 	}
 
 	return result, nil
@@ -2,12 +2,21 @@ package redis
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"cache-chain/pkg/cache"
 )
 
+// RedisCache implements the optional layer interfaces
+var (
+	_ cache.BatchEntryLayer = (*RedisCache)(nil)
+	_ cache.TaggedLayer     = (*RedisCache)(nil)
+	_ cache.PrefixLayer     = (*RedisCache)(nil)
+	_ cache.FencedLayer     = (*RedisCache)(nil)
+)
+
 func skipIfNoRedis(t *testing.T, r *RedisCache) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -185,3 +194,475 @@ func TestNewRedisCache_NoAddressError(t *testing.T) {
 		t.Error("Expected error when no addresses configured")
 	}
 }
+
+func TestRedisCache_InvalidationBus(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	bus := r.NewInvalidationBus("")
+	defer bus.Close()
+
+	received := make(chan []string, 1)
+	unsubscribe, err := bus.Subscribe(func(origin string, keys []string) {
+		if origin == "node-a" {
+			received <- keys
+		}
+	})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer unsubscribe()
+
+	// Give the subscription time to be established
+	time.Sleep(100 * time.Millisecond)
+
+	ctx := context.Background()
+	if err := bus.Publish(ctx, "node-a", "key1", "key2"); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	select {
+	case keys := <-received:
+		if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+			t.Errorf("Unexpected keys: %v", keys)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Invalidation not received")
+	}
+}
+
+func TestRedisCache_ClientSideCache(t *testing.T) {
+	config := DefaultRedisCacheConfig()
+	config.Name = "TestRedisCSC"
+	config.KeyPrefix = "test:csc:"
+	config.ClientSideCacheTTL = time.Minute
+
+	r, err := NewRedisCache(config)
+	if err != nil {
+		t.Skipf("Failed to create Redis client: %v", err)
+	}
+	defer r.Close()
+	skipIfNoRedis(t, r)
+
+	ctx := context.Background()
+	if err := r.Set(ctx, "key1", "value1", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		val, err := r.Get(ctx, "key1")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if val != "value1" {
+			t.Errorf("Expected 'value1', got '%v'", val)
+		}
+	}
+
+	stats := r.Stats()
+	if !stats.ClientSideCacheEnabled {
+		t.Error("Expected client-side cache to be enabled")
+	}
+	if stats.ClientCacheMisses != 1 || stats.ClientCacheHits != 2 {
+		t.Errorf("Expected 1 miss and 2 hits, got %+v", stats)
+	}
+
+	// Server-pushed invalidation: the next read must see the new value
+	if err := r.Set(ctx, "key1", "value2", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	val, err := r.Get(ctx, "key1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if val != "value2" {
+		t.Errorf("Expected 'value2' after invalidation, got '%v'", val)
+	}
+}
+
+func TestRedisCache_Entry(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	entry := &cache.CacheEntry{
+		Key:          "entry",
+		Value:        "value",
+		StaleAt:      now.Add(time.Minute),
+		ExpiresAt:    now.Add(time.Hour),
+		CreatedAt:    now,
+		Version:      7,
+		LoadDuration: 250 * time.Millisecond,
+	}
+
+	if err := r.SetEntry(ctx, entry); err != nil {
+		t.Fatalf("SetEntry failed: %v", err)
+	}
+
+	got, err := r.GetEntry(ctx, "entry")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if got.Value != "value" || !got.StaleAt.Equal(entry.StaleAt) || got.Version != 7 || got.LoadDuration != entry.LoadDuration {
+		t.Errorf("Expected entry with metadata, got %+v", got)
+	}
+
+	// Plain Get still reads entries
+	if val, err := r.Get(ctx, "entry"); err != nil || val != "value" {
+		t.Errorf("Expected 'value', got %v (err: %v)", val, err)
+	}
+
+	ttl, err := r.TTL(ctx, "entry")
+	if err != nil || ttl < 59*time.Minute {
+		t.Errorf("Expected ~1h TTL from ExpiresAt, got %v (err: %v)", ttl, err)
+	}
+}
+
+func TestRedisCache_EntryTTL(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+
+	// Plain values report their expiry through PTTL
+	if err := r.Set(ctx, "plain", "value", 30*time.Second); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	entry, err := r.GetEntry(ctx, "plain")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if ttl := entry.TimeToLive(); ttl > 30*time.Second || ttl < 29*time.Second {
+		t.Errorf("Expected ~30s remaining TTL, got %v", ttl)
+	}
+}
+
+func TestRedisCache_Lease(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+	lease := r.NewLease("")
+
+	token, acquired, err := lease.Acquire(ctx, "key", time.Second)
+	if err != nil || !acquired {
+		t.Fatalf("Expected lease to be acquired, got %v (err: %v)", acquired, err)
+	}
+
+	// Held: a second acquire fails
+	if _, acquired, err := lease.Acquire(ctx, "key", time.Second); err != nil || acquired {
+		t.Errorf("Expected held lease to be refused, got %v (err: %v)", acquired, err)
+	}
+
+	if held, err := lease.Holds(ctx, "key", token); err != nil || !held {
+		t.Errorf("Expected token to hold the lease, got %v (err: %v)", held, err)
+	}
+
+	// Releasing with another token is a no-op
+	lease.Release(ctx, "key", token+100)
+	if held, _ := lease.Holds(ctx, "key", token); !held {
+		t.Error("Expected lease to survive release with a wrong token")
+	}
+
+	if err := lease.Release(ctx, "key", token); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+
+	// The next holder gets a new fencing token
+	next, acquired, err := lease.Acquire(ctx, "key", 50*time.Millisecond)
+	if err != nil || !acquired {
+		t.Fatalf("Expected lease to be acquired after release, got %v (err: %v)", acquired, err)
+	}
+	if next <= token {
+		t.Errorf("Expected increasing fencing token, got %d after %d", next, token)
+	}
+
+	// Leases expire with their TTL
+	time.Sleep(100 * time.Millisecond)
+	if held, _ := lease.Holds(ctx, "key", next); held {
+		t.Error("Expected lease to expire")
+	}
+}
+
+func TestRedisCache_SetEntryFenced(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+	lease := r.NewLease("")
+	defer r.Delete(ctx, "fenced")
+
+	token, acquired, err := lease.Acquire(ctx, "fenced", time.Second)
+	if err != nil || !acquired {
+		t.Fatalf("Expected lease to be acquired, got %v (err: %v)", acquired, err)
+	}
+
+	entry := &cache.CacheEntry{Key: "fenced", Value: "first", ExpiresAt: time.Now().Add(time.Minute)}
+	if stored, err := r.SetEntryFenced(ctx, entry, lease.FenceKey("fenced"), token); err != nil || !stored {
+		t.Fatalf("Expected holder's write to be stored, got %v (err: %v)", stored, err)
+	}
+
+	// Once the lease is gone, the old token can't write any more
+	lease.Release(ctx, "fenced", token)
+	entry.Value = "late"
+	if stored, err := r.SetEntryFenced(ctx, entry, lease.FenceKey("fenced"), token); err != nil || stored {
+		t.Errorf("Expected fenced write to be refused, got %v (err: %v)", stored, err)
+	}
+
+	value, err := r.Get(ctx, "fenced")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value != "first" {
+		t.Errorf("Expected 'first', got %v", value)
+	}
+}
+
+func TestLease_FenceKeySlot(t *testing.T) {
+	lease := &Lease{prefix: "lease:", keyPrefix: "app:"}
+
+	for _, key := range []string{"user:1", "{user1000}.following", "a{}b", "a}b{c"} {
+		if keySlot(lease.FenceKey(key)) != keySlot("app:"+key) {
+			t.Errorf("Expected lease key of %q to share the value's slot", key)
+		}
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		slot uint16
+	}{
+		{"123456789", 12739},
+		{"foo", 12182},
+		{"bar", 5061},
+		{"{foo}bar", 12182}, // hash tag
+	}
+
+	for _, tt := range tests {
+		if slot := keySlot(tt.key); slot != tt.slot {
+			t.Errorf("keySlot(%q) = %d, want %d", tt.key, slot, tt.slot)
+		}
+	}
+
+	// Empty hash tags hash the whole key
+	if keySlot("{}foo") == keySlot("foo") {
+		t.Error("Expected empty hash tag to be ignored")
+	}
+	if keySlot("{user1000}.following") != keySlot("{user1000}.followers") {
+		t.Error("Expected keys with the same hash tag to share a slot")
+	}
+}
+
+func TestRedisCache_GroupBySlot(t *testing.T) {
+	keys := []string{"{a}1", "{b}1", "{a}2", "{b}2", "c"}
+
+	// Standalone: a single group
+	standalone := &RedisCache{config: RedisCacheConfig{KeyPrefix: "p:"}}
+	if groups := standalone.groupBySlot(keys); len(groups) != 1 || len(groups[0].fullKeys) != 5 {
+		t.Errorf("Expected one group of 5 keys, got %d groups", len(groups))
+	}
+
+	// Cluster: one group per slot, keeping the caller's keys
+	cluster := &RedisCache{cluster: true}
+	groups := cluster.groupBySlot(keys)
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 slot groups, got %d", len(groups))
+	}
+	for _, g := range groups {
+		slot := keySlot(g.fullKeys[0])
+		for _, key := range g.fullKeys {
+			if keySlot(key) != slot {
+				t.Errorf("Group mixes slots: %v", g.fullKeys)
+			}
+		}
+	}
+	if len(groups[0].keys) != 2 || groups[0].keys[0] != "{a}1" || groups[0].keys[1] != "{a}2" {
+		t.Errorf("Expected first group to hold {a}1 and {a}2, got %v", groups[0].keys)
+	}
+}
+
+func TestRedisCache_Multi(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+	items := map[string]interface{}{"a": "1", "b": "2", "{tag}c": "3"}
+
+	if err := r.SetMulti(ctx, items, time.Minute); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+
+	results, err := r.GetMulti(ctx, []string{"a", "b", "{tag}c", "missing"})
+	if err != nil {
+		t.Fatalf("GetMulti failed: %v", err)
+	}
+	if len(results) != 3 || results["a"] != "1" || results["{tag}c"] != "3" {
+		t.Errorf("Expected 3 values, got %v", results)
+	}
+
+	entries, err := r.GetMultiEntries(ctx, []string{"a", "{tag}c", "missing"})
+	if err != nil {
+		t.Fatalf("GetMultiEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries["{tag}c"].Value != "3" {
+		t.Errorf("Expected 2 entries, got %v", entries)
+	}
+	if remaining := time.Until(entries["a"].ExpiresAt); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("Expected expiry from the key's PTTL, got %v", remaining)
+	}
+
+	// Undecodable values fail alone
+	r.client.Do(ctx, r.client.B().Set().Key(r.config.KeyPrefix+"corrupt").Value("\x00garbage").Build())
+	results, err = r.GetMulti(ctx, []string{"a", "corrupt"})
+	batchErr, ok := cache.AsBatchError(err)
+	if !ok || len(batchErr.Errors) != 1 || batchErr.Errors["corrupt"] == nil {
+		t.Errorf("Expected per-key error for corrupt, got %v", err)
+	}
+	if results["a"] != "1" {
+		t.Errorf("Expected partial result with a, got %v", results)
+	}
+
+	if err := r.DeleteMulti(ctx, []string{"a", "b", "{tag}c"}); err != nil {
+		t.Fatalf("DeleteMulti failed: %v", err)
+	}
+	if results, _ := r.GetMulti(ctx, []string{"a", "b", "{tag}c"}); len(results) != 0 {
+		t.Errorf("Expected keys to be deleted, got %v", results)
+	}
+}
+
+func TestRedisCache_TTLPrecision(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+
+	// Sub-second TTLs are kept to the millisecond instead of being rejected
+	if err := r.Set(ctx, "short", "v", 500*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := r.SetMulti(ctx, map[string]interface{}{"short-multi": "v"}, 500*time.Millisecond); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+	for _, key := range []string{"short", "short-multi"} {
+		entry, err := r.GetEntry(ctx, key)
+		if err != nil {
+			t.Fatalf("GetEntry failed: %v", err)
+		}
+		if ttl := time.Until(entry.ExpiresAt); ttl <= 0 || ttl > 500*time.Millisecond {
+			t.Errorf("Expected %s to expire within 500ms, got %v", key, ttl)
+		}
+	}
+
+	// A zero TTL stores without expiry
+	if err := r.SetMulti(ctx, map[string]interface{}{"forever": "v"}, 0); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+	if err := r.SetWithTags(ctx, "forever-tagged", "v", 0, []string{"t"}); err != nil {
+		t.Fatalf("SetWithTags failed: %v", err)
+	}
+	for _, key := range []string{"forever", "forever-tagged"} {
+		if _, err := r.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to be stored, got %v", key, err)
+		}
+	}
+}
+
+func TestRedisCache_Tags(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+	r.SetWithTags(ctx, "order:1", "a", time.Minute, []string{"account:42"})
+	r.SetWithTags(ctx, "order:2", "b", time.Minute, []string{"account:42", "region:eu"})
+	r.SetWithTags(ctx, "order:3", "c", time.Minute, []string{"account:7"})
+	r.SetWithTags(ctx, "order:4", "d", 50*time.Millisecond, []string{"account:7"})
+
+	ttl, _ := r.client.Do(ctx, r.client.B().Pttl().Key(r.config.TagPrefix+"account:7").Build()).AsInt64()
+	if ttl <= int64(50) {
+		t.Errorf("Expected tag set to live as long as its longest member, got %dms", ttl)
+	}
+
+	keys, err := r.InvalidateTag(ctx, "account:42")
+	if err != nil {
+		t.Fatalf("InvalidateTag failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected 2 invalidated keys, got %v", keys)
+	}
+	for _, key := range []string{"order:1", "order:2"} {
+		if _, err := r.Get(ctx, key); err != cache.ErrCacheMiss {
+			t.Errorf("Expected %s to be invalidated, got %v", key, err)
+		}
+	}
+	if val, _ := r.Get(ctx, "order:3"); val != "c" {
+		t.Errorf("Expected order:3 to survive, got %v", val)
+	}
+
+	// Expired members are pruned from the index
+	time.Sleep(100 * time.Millisecond)
+	removed, err := r.PruneTag(ctx, "account:7")
+	if err != nil {
+		t.Fatalf("PruneTag failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 pruned member, got %d", removed)
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	tests := map[string]string{
+		"user:":       "user:",
+		"a*b?":        `a\*b\?`,
+		"[x]":         `\[x\]`,
+		`back\slash:`: `back\\slash:`,
+	}
+	for input, expected := range tests {
+		if got := escapePattern(input); got != expected {
+			t.Errorf("escapePattern(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestRedisCache_DeletePrefix(t *testing.T) {
+	r := setupTestRedis(t)
+	defer r.Close()
+
+	ctx := context.Background()
+	items := make(map[string]interface{})
+	for i := 0; i < 2500; i++ {
+		items[fmt.Sprintf("user:%d", i)] = i
+	}
+	items["users"] = "other"
+	items["user*"] = "other"
+	if err := r.SetMulti(ctx, items, time.Minute); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+
+	reported := 0
+	deleted, err := r.DeletePrefix(ctx, "user:", func(keys []string) {
+		reported += len(keys)
+	})
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if deleted != 2500 || reported != 2500 {
+		t.Errorf("Expected 2500 deleted and reported, got %d and %d", deleted, reported)
+	}
+
+	keys, err := r.Keys(ctx, "*")
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected users and user* to remain, got %v", keys)
+	}
+
+	// Glob characters in the prefix are literal
+	if deleted, _ := r.DeletePrefix(ctx, "user*", nil); deleted != 1 {
+		t.Errorf("Expected only user* to be deleted, got %d", deleted)
+	}
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Redis Cluster distributes keys over 16384 hash slots. Multi-key commands
+// (MGET, DEL) must only address keys of a single slot.
+const clusterSlots = 16384
+
+// keySlot returns the cluster hash slot of key: CRC16 of its hash tag.
+func keySlot(key string) uint16 {
+	return crc16(hashTag(key)) % clusterSlots
+}
+
+// hashTag returns the part of key Redis Cluster hashes: the part between the
+// first "{" and the next "}" if non-empty, the whole key otherwise.
+func hashTag(key string) string {
+	for i := 0; i < len(key); i++ {
+		if key[i] != '{' {
+			continue
+		}
+		for j := i + 1; j < len(key); j++ {
+			if key[j] == '}' {
+				if j > i+1 {
+					return key[i+1 : j]
+				}
+				break
+			}
+		}
+		break
+	}
+	return key
+}
+
+// slotTags returns, for each cluster slot, a short hash tag mapping to it.
+// Prefixing a key with "{" + tag + "}" moves it to that slot whatever the
+// key contains.
+var slotTags = sync.OnceValue(func() []string {
+	tags := make([]string, clusterSlots)
+	for found, n := 0, 0; found < clusterSlots; n++ {
+		tag := strconv.Itoa(n)
+		if slot := crc16(tag) % clusterSlots; tags[slot] == "" {
+			tags[slot] = tag
+			found++
+		}
+	}
+	return tags
+})
+
+// crc16 implements CRC-16/XMODEM, the checksum used by Redis Cluster.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
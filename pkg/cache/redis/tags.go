@@ -0,0 +1,144 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+	"go.uber.org/zap"
+)
+
+// SetWithTags stores a value like Set and adds its key to one Redis set per
+// tag, all in a single pipeline. Each tag set expires with its longest-lived
+// member, so tags whose keys all expired disappear on their own; a value
+// stored without expiry (ttl <= 0) keeps its tag sets forever. Members whose
+// keys expired earlier are removed by InvalidateTag or PruneTag.
+func (r *RedisCache) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags []string) error {
+	fullKey := r.config.KeyPrefix + key
+
+	data, err := r.encode(value)
+	if err != nil {
+		r.logger.Error("failed to marshal",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return fmt.Errorf("redis set: failed to marshal: %w", err)
+	}
+
+	ms := ttl.Milliseconds()
+	if ttl > 0 && ms == 0 {
+		ms = 1 // as rounded up by setCmd
+	}
+	cmds := make(rueidis.Commands, 0, 1+3*len(tags))
+	cmds = append(cmds, r.setCmd(fullKey, data, ttl))
+	for _, tag := range tags {
+		tagKey := r.config.TagPrefix + tag
+		cmds = append(cmds, r.client.B().Sadd().Key(tagKey).Member(key).Build())
+		if ttl <= 0 {
+			// The value never expires, so neither may its tag
+			cmds = append(cmds, r.client.B().Persist().Key(tagKey).Build())
+			continue
+		}
+		cmds = append(cmds,
+			// NX covers new sets, GT extends sets whose members expire sooner
+			r.client.B().Pexpire().Key(tagKey).Milliseconds(ms).Nx().Build(),
+			r.client.B().Pexpire().Key(tagKey).Milliseconds(ms).Gt().Build(),
+		)
+	}
+
+	for _, resp := range r.client.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			r.logger.Error("redis set with tags error",
+				zap.String("key", key),
+				zap.Strings("tags", tags),
+				zap.Error(err),
+			)
+			return fmt.Errorf("redis set: %w", err)
+		}
+	}
+
+	r.logger.Debug("cache set with tags",
+		zap.String("key", key),
+		zap.Duration("ttl", ttl),
+		zap.Strings("tags", tags),
+	)
+
+	return nil
+}
+
+// InvalidateTag deletes every key in the tag's set and returns them.
+// Only the members read are removed from the set, so keys tagged while the
+// invalidation runs are kept. Keys overwritten with Set since they were
+// tagged are still deleted.
+func (r *RedisCache) InvalidateTag(ctx context.Context, tag string) ([]string, error) {
+	tagKey := r.config.TagPrefix + tag
+
+	keys, err := r.client.Do(ctx, r.client.B().Smembers().Key(tagKey).Build()).AsStrSlice()
+	if err != nil {
+		return nil, fmt.Errorf("redis invalidate tag: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	if err := r.DeleteMulti(ctx, keys); err != nil {
+		return keys, err
+	}
+	if err := r.client.Do(ctx, r.client.B().Srem().Key(tagKey).Member(keys...).Build()).Error(); err != nil {
+		return keys, fmt.Errorf("redis invalidate tag: %w", err)
+	}
+
+	r.logger.Debug("tag invalidated",
+		zap.String("tag", tag),
+		zap.Int("keys", len(keys)),
+	)
+
+	return keys, nil
+}
+
+// PruneTag removes the members of the tag's set whose keys no longer exist
+// and returns how many were removed. Useful for long-lived tags whose
+// members keep expiring while new ones are added.
+func (r *RedisCache) PruneTag(ctx context.Context, tag string) (int, error) {
+	tagKey := r.config.TagPrefix + tag
+
+	keys, err := r.client.Do(ctx, r.client.B().Smembers().Key(tagKey).Build()).AsStrSlice()
+	if err != nil {
+		return 0, fmt.Errorf("redis prune tag: %w", err)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	cmds := make(rueidis.Commands, len(keys))
+	for i, key := range keys {
+		cmds[i] = r.client.B().Exists().Key(r.config.KeyPrefix + key).Build()
+	}
+
+	var stale []string
+	for i, resp := range r.client.DoMulti(ctx, cmds...) {
+		n, err := resp.AsInt64()
+		if err != nil {
+			return 0, fmt.Errorf("redis prune tag: %w", err)
+		}
+		if n == 0 {
+			stale = append(stale, keys[i])
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	if err := r.client.Do(ctx, r.client.B().Srem().Key(tagKey).Member(stale...).Build()).Error(); err != nil {
+		return 0, fmt.Errorf("redis prune tag: %w", err)
+	}
+
+	r.logger.Debug("tag pruned",
+		zap.String("tag", tag),
+		zap.Int("removed", len(stale)),
+		zap.Int("members", len(keys)),
+	)
+
+	return len(stale), nil
+}
@@ -0,0 +1,318 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cache-chain/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// BatchLoaderFunc loads several keys from the origin after a full-chain miss.
+// It returns the values found along with the TTL they should be cached with.
+// Keys missing from the returned map don't exist at the origin.
+type BatchLoaderFunc func(ctx context.Context, keys []string) (map[string]interface{}, time.Duration, error)
+
+// GetMulti retrieves several keys at once. L1 is asked for all keys, L2 only
+// for the keys L1 missed, and so on. Keys found in a lower layer are warmed
+// into the layers above it in the background. Layers implementing
+// cache.BatchCacheLayer are read natively.
+//
+// Keys found in no layer are absent from the result, which is not an error.
+// If a layer failed and some keys couldn't be resolved, the error is
+// returned along with the keys that were found.
+func (c *Chain) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	results, missing, err := c.getMulti(ctx, keys)
+	if len(missing) == 0 {
+		return results, nil
+	}
+	return results, err
+}
+
+// GetMultiOrLoad is like GetMulti but loads the keys missing from every layer
+// with loader, in a single call, and writes the loaded values to all layers
+// (to the caches only under a WritePolicy).
+func (c *Chain) GetMultiOrLoad(ctx context.Context, keys []string, loader BatchLoaderFunc) (map[string]interface{}, error) {
+	if loader == nil {
+		return nil, errors.New("chain: loader is required")
+	}
+
+	results, missing, err := c.getMulti(ctx, keys)
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	// Don't hit the origin on behalf of a caller that gave up
+	if ctx.Err() != nil {
+		return results, ctx.Err()
+	}
+	if err != nil {
+		c.logger.Warn("batch get failed on some layers - loading remaining keys",
+			zap.Int("missing", len(missing)),
+			zap.Error(err),
+		)
+	}
+
+	start := time.Now()
+	loaded, ttl, err := loader(ctx, missing)
+	duration := time.Since(start)
+	c.recordLoad(err == nil, duration)
+
+	if err != nil {
+		c.logger.Warn("origin batch load failed",
+			zap.Int("keys", len(missing)),
+			zap.Duration("duration", duration),
+			zap.Error(err),
+		)
+		return results, err
+	}
+
+	c.logger.Debug("origin batch load completed",
+		zap.Int("requested", len(missing)),
+		zap.Int("loaded", len(loaded)),
+		zap.Duration("ttl", ttl),
+		zap.Duration("duration", duration),
+	)
+
+	if len(loaded) > 0 {
+		if err := c.setMulti(ctx, loaded, ttl, true); err != nil {
+			c.logger.Warn("failed to populate layers after batch load",
+				zap.Int("keys", len(loaded)),
+				zap.Error(err),
+			)
+		}
+	}
+
+	for key, value := range loaded {
+		results[key] = value
+	}
+	return results, nil
+}
+
+// getMulti walks the layers, asking each one for the keys still missing.
+// It returns the values found, the keys found nowhere and the last layer
+// error, which is nil if the keys are simply missing.
+func (c *Chain) getMulti(ctx context.Context, keys []string) (map[string]interface{}, []string, error) {
+	start := time.Now()
+	results := make(map[string]interface{}, len(keys))
+	missing := uniqueKeys(keys)
+
+	var lastErr error
+	for i, layer := range c.layers {
+		if len(missing) == 0 {
+			break
+		}
+
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			return results, missing, ctx.Err()
+		default:
+		}
+
+		found, err := getMultiEntries(ctx, layer, missing)
+		if err != nil {
+			// Keep whatever the layer returned and ask the next one for the rest
+			c.logger.Warn("layer batch error - falling back to next",
+				zap.Int("layer_index", i),
+				zap.String("layer_name", layer.Name()),
+				zap.Int("keys", len(missing)),
+				zap.Error(err),
+			)
+			lastErr = err
+		}
+		if len(found) == 0 {
+			continue
+		}
+
+		remaining := missing[:0:0]
+		for _, key := range missing {
+			entry, ok := found[key]
+			if !ok {
+				remaining = append(remaining, key)
+				continue
+			}
+			results[key] = entry.Value
+			c.metrics.RecordChainGet(true, i, time.Since(start))
+
+			// Warm up upper layers asynchronously, like Get
+			if i > 0 {
+				c.warmUpperLayers(ctx, entry, i)
+				c.grace.remember(entry)
+			}
+		}
+		missing = remaining
+	}
+
+	for range missing {
+		c.metrics.RecordChainGet(false, -1, time.Since(start))
+	}
+
+	c.logger.Debug("chain batch get completed",
+		zap.Int("keys", len(keys)),
+		zap.Int("found", len(results)),
+		zap.Int("missing", len(missing)),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return results, missing, lastErr
+}
+
+// getMultiEntries reads keys from a layer with their metadata, adapting
+// layers without batch entry reads.
+func getMultiEntries(ctx context.Context, layer cache.CacheLayer, keys []string) (map[string]*cache.CacheEntry, error) {
+	if bl, ok := layer.(cache.BatchEntryLayer); ok {
+		return bl.GetMultiEntries(ctx, keys)
+	}
+	return cache.NewBatchAdapter(layer).GetMultiEntries(ctx, keys)
+}
+
+// SetMulti writes several values to all layers, using native batch writes
+// where a layer supports them. The TTL is adjusted per layer (and per key)
+// using the configured TTLStrategy. With StaleWhileRevalidate enabled, values
+// are written one by one to keep their soft TTL metadata.
+// With a WritePolicy, the origin is written as the policy dictates.
+// If an InvalidationBus is configured, other instances are told to evict the keys.
+func (c *Chain) SetMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	return c.setMulti(ctx, items, ttl, false)
+}
+
+// setMulti writes items like SetMulti. Values loaded from the origin only go
+// to the caches.
+func (c *Chain) setMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration, loaded bool) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	update := func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		return c.setMultiLayer(ctx, i, layer, items, ttl)
+	}
+	if c.staleWhileRevalidate > 0 {
+		update = func(ctx context.Context, i int, layer cache.CacheLayer) error {
+			var lastErr error
+			for key, value := range items {
+				if err := c.setLayer(key, value, ttl)(ctx, i, layer); err != nil {
+					lastErr = err
+				}
+			}
+			return lastErr
+		}
+	}
+
+	var err error
+	if loaded {
+		err = c.writeCaches(ctx, update)
+	} else {
+		err = c.write(ctx, keys, update)
+		if errors.Is(err, ErrOriginWrite) {
+			return err
+		}
+	}
+
+	expiresAt := time.Now().Add(ttl + c.staleWhileRevalidate)
+	for key, value := range items {
+		c.grace.remember(&cache.CacheEntry{Key: key, Value: value, ExpiresAt: expiresAt})
+	}
+	c.publishInvalidation(ctx, keys...)
+
+	return err
+}
+
+// setMultiLayer writes items to layer, the chain's layer at index i. Keys are
+// grouped by their layer TTL, so strategies that vary the TTL per key still
+// get one write per group.
+func (c *Chain) setMultiLayer(ctx context.Context, i int, layer cache.CacheLayer, items map[string]interface{}, ttl time.Duration) error {
+	groups := make(map[time.Duration]map[string]interface{})
+	for key, value := range items {
+		layerTTL := c.layerTTL(i, key, ttl)
+		group, ok := groups[layerTTL]
+		if !ok {
+			group = make(map[string]interface{})
+			groups[layerTTL] = group
+		}
+		group[key] = value
+	}
+
+	bl := batchLayer(layer)
+	var lastErr error
+	for layerTTL, group := range groups {
+		if err := bl.SetMulti(ctx, group, layerTTL); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// DeleteMulti removes several keys from all layers, using native batch
+// deletes where a layer supports them.
+// With a WritePolicy, the origin is written as the policy dictates.
+// If an InvalidationBus is configured, other instances are told to evict the keys.
+func (c *Chain) DeleteMulti(ctx context.Context, keys []string) error {
+	keys = uniqueKeys(keys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := c.write(ctx, keys, func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		return batchLayer(layer).DeleteMulti(ctx, keys)
+	})
+	if errors.Is(err, ErrOriginWrite) {
+		return err
+	}
+
+	c.evicted(ctx, keys)
+
+	return err
+}
+
+// evict removes keys from the caches only, leaving the origin alone.
+func (c *Chain) evict(ctx context.Context, keys []string) error {
+	keys = uniqueKeys(keys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := c.writeCaches(ctx, func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		return batchLayer(layer).DeleteMulti(ctx, keys)
+	})
+	c.evicted(ctx, keys)
+	return err
+}
+
+// evicted forgets removed keys for stale-if-error and tells other instances
+// to evict their copy.
+func (c *Chain) evicted(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		c.grace.forget(key)
+	}
+	c.publishInvalidation(ctx, keys...)
+}
+
+// batchLayer returns the layer's batch interface, adapting layers without one.
+func batchLayer(layer cache.CacheLayer) cache.BatchCacheLayer {
+	if bl, ok := layer.(cache.BatchCacheLayer); ok {
+		return bl
+	}
+	return cache.NewBatchAdapter(layer)
+}
+
+// uniqueKeys returns keys without duplicates, keeping their order.
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]struct{}, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, key)
+	}
+	return unique
+}
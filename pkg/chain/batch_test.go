@@ -0,0 +1,289 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
+)
+
+// recordingBatchLayer is a native batch layer that records the keys it's asked for.
+type recordingBatchLayer struct {
+	*cache.BatchAdapter
+
+	mu      sync.Mutex
+	getKeys [][]string
+	getErr  error
+}
+
+func newRecordingBatchLayer(name string) *recordingBatchLayer {
+	return &recordingBatchLayer{
+		BatchAdapter: cache.NewBatchAdapter(memory.NewMemoryCache(memory.MemoryCacheConfig{Name: name})),
+	}
+}
+
+func (l *recordingBatchLayer) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	if err := l.record(keys); err != nil {
+		return nil, err
+	}
+	return l.BatchAdapter.GetMulti(ctx, keys)
+}
+
+func (l *recordingBatchLayer) GetMultiEntries(ctx context.Context, keys []string) (map[string]*cache.CacheEntry, error) {
+	if err := l.record(keys); err != nil {
+		return nil, err
+	}
+	return l.BatchAdapter.GetMultiEntries(ctx, keys)
+}
+
+func (l *recordingBatchLayer) record(keys []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.getKeys = append(l.getKeys, append([]string(nil), keys...))
+	return l.getErr
+}
+
+func (l *recordingBatchLayer) requested() [][]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.getKeys
+}
+
+func sortedKeys(keys []string) []string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestChain_GetMulti_PerLayerFallback(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := newRecordingBatchLayer("L2")
+	l3 := newRecordingBatchLayer("L3")
+
+	c, err := New(l1, l2, l3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	l1.Set(ctx, "a", "value-a", time.Minute)
+	l2.Set(ctx, "b", "value-b", time.Minute)
+	l3.Set(ctx, "c", "value-c", time.Minute)
+
+	// Missing keys are simply absent
+	results, err := c.GetMulti(ctx, []string{"a", "b", "c", "d", "a"})
+	if err != nil {
+		t.Errorf("Expected no error for the missing key, got %v", err)
+	}
+	if len(results) != 3 || results["a"] != "value-a" || results["b"] != "value-b" || results["c"] != "value-c" {
+		t.Errorf("Expected a, b and c, got %v", results)
+	}
+
+	// Each layer is only asked for what the layers above it missed
+	if got := l2.requested(); len(got) != 1 || len(got[0]) != 3 {
+		t.Errorf("Expected L2 to be asked for b, c and d, got %v", got)
+	}
+	if got := l3.requested(); len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("Expected L3 to be asked for c and d, got %v", got)
+	}
+
+	// Found keys are warmed into the upper layers for the time they have left
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	for _, key := range []string{"b", "c"} {
+		entry, err := l1.GetEntry(ctx, key)
+		if err != nil {
+			t.Errorf("Expected %s to be warmed into L1, got %v", key, err)
+			continue
+		}
+		if remaining := time.Until(entry.ExpiresAt); remaining > time.Minute {
+			t.Errorf("Expected %s to be warmed for at most its remaining TTL, got %v", key, remaining)
+		}
+	}
+	if _, err := l2.Get(ctx, "c"); err != nil {
+		t.Errorf("Expected c to be warmed into L2, got %v", err)
+	}
+
+	// All found: no error
+	if _, err := c.GetMulti(ctx, []string{"a", "b"}); err != nil {
+		t.Errorf("Expected no error when all keys are found, got %v", err)
+	}
+}
+
+func TestChain_GetMulti_LayerError(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := newRecordingBatchLayer("L2")
+	l2.getErr = errors.New("connection refused")
+
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	l1.Set(ctx, "a", "value-a", time.Minute)
+
+	// Keys that couldn't be resolved report the layer error with the partial result
+	results, err := c.GetMulti(ctx, []string{"a", "b"})
+	if err == nil || cache.IsNotFound(err) {
+		t.Errorf("Expected layer error, got %v", err)
+	}
+	if results["a"] != "value-a" {
+		t.Errorf("Expected partial result with a, got %v", results)
+	}
+}
+
+func TestChain_GetMulti_WarmsUnknownExpiry(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	// L2 stores plain values, so their expiry is unknown
+	l2 := mock.NewMockLayer("L2")
+	l2.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		if key == "a" {
+			return "value-a", nil
+		}
+		return nil, cache.ErrKeyNotFound
+	}
+
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	results, err := c.GetMulti(ctx, []string{"a", "b"})
+	if err != nil || results["a"] != "value-a" {
+		t.Fatalf("Expected a, got %v (%v)", results, err)
+	}
+
+	// Warmed into L1 for the default warm-up TTL, like Get does
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	entry, err := l1.GetEntry(ctx, "a")
+	if err != nil {
+		t.Fatalf("Expected a to be warmed into L1, got %v", err)
+	}
+	if remaining := time.Until(entry.ExpiresAt); remaining <= 0 || remaining > defaultWarmUpTTL {
+		t.Errorf("Expected a to be warmed for at most %v, got %v", defaultWarmUpTTL, remaining)
+	}
+}
+
+func TestChain_GetMultiOrLoad(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	l1.Set(ctx, "a", "value-a", time.Minute)
+
+	var calls int32
+	var requested []string
+	loader := func(ctx context.Context, keys []string) (map[string]interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		requested = keys
+		values := make(map[string]interface{})
+		for _, key := range keys {
+			if key != "missing" {
+				values[key] = "loaded-" + key
+			}
+		}
+		return values, time.Minute, nil
+	}
+
+	results, err := c.GetMultiOrLoad(ctx, []string{"a", "b", "c", "missing"}, loader)
+	if err != nil {
+		t.Fatalf("GetMultiOrLoad failed: %v", err)
+	}
+	if len(results) != 3 || results["a"] != "value-a" || results["b"] != "loaded-b" || results["c"] != "loaded-c" {
+		t.Errorf("Expected a, b and c, got %v", results)
+	}
+	if got := sortedKeys(requested); len(got) != 3 || got[0] != "b" || got[1] != "c" || got[2] != "missing" {
+		t.Errorf("Expected loader to be asked for the misses only, got %v", got)
+	}
+
+	// Loaded values are written to all layers
+	for _, layer := range []*memory.MemoryCache{l1, l2} {
+		if value, err := layer.Get(ctx, "b"); err != nil || value != "loaded-b" {
+			t.Errorf("%s: expected loaded-b, got %v (err: %v)", layer.Name(), value, err)
+		}
+	}
+
+	// Second call is served from cache
+	if _, err := c.GetMultiOrLoad(ctx, []string{"a", "b", "c"}, loader); err != nil {
+		t.Fatalf("GetMultiOrLoad failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 loader call, got %d", n)
+	}
+
+	// Loader errors are returned with what the layers found
+	loadErr := errors.New("database down")
+	results, err = c.GetMultiOrLoad(ctx, []string{"a", "x"}, func(ctx context.Context, keys []string) (map[string]interface{}, time.Duration, error) {
+		return nil, 0, loadErr
+	})
+	if !errors.Is(err, loadErr) {
+		t.Errorf("Expected loader error, got %v", err)
+	}
+	if results["a"] != "value-a" {
+		t.Errorf("Expected partial result with a, got %v", results)
+	}
+}
+
+func TestChain_SetMulti_DeleteMulti(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := newRecordingBatchLayer("L2")
+
+	strategy := &CustomTTLStrategy{TTLs: []time.Duration{time.Minute}}
+	c, err := NewWithConfig(ChainConfig{TTLStrategy: strategy}, l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	items := map[string]interface{}{"a": 1, "b": 2, "c": 3}
+
+	if err := c.SetMulti(ctx, items, time.Hour); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+
+	for key, expected := range items {
+		entry, err := l1.GetEntry(ctx, key)
+		if err != nil || entry.Value != expected {
+			t.Errorf("L1 %s: expected %v, got %+v (err: %v)", key, expected, entry, err)
+			continue
+		}
+		if ttl := entry.TimeToLive(); ttl > time.Minute {
+			t.Errorf("L1 %s: expected TTL from strategy, got %v", key, ttl)
+		}
+		if value, err := l2.Get(ctx, key); err != nil || value != expected {
+			t.Errorf("L2 %s: expected %v, got %v (err: %v)", key, expected, value, err)
+		}
+	}
+
+	if err := c.DeleteMulti(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("DeleteMulti failed: %v", err)
+	}
+
+	results, _ := c.GetMulti(ctx, []string{"a", "b", "c"})
+	if len(results) != 1 || results["c"] != 3 {
+		t.Errorf("Expected only c to remain, got %v", results)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"cache-chain/pkg/cache"
@@ -12,6 +15,7 @@ import (
 	"cache-chain/pkg/resilience"
 	"cache-chain/pkg/writer"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 )
@@ -25,6 +29,37 @@ type Chain struct {
 	metrics     metrics.MetricsCollector
 	ttlStrategy TTLStrategy
 	logger      *logging.Logger
+	codec       cache.Codec
+
+	// Cross-instance invalidation (optional)
+	bus         InvalidationBus
+	nodeID      string
+	localLayers int
+	unsubscribe func()
+
+	// Stale-while-revalidate (optional)
+	staleWhileRevalidate time.Duration
+	loader               atomic.Pointer[KeyLoaderFunc]
+	refreshing           sync.Map // keys with a background refresh in flight
+	refreshWG            sync.WaitGroup
+	refreshCtx           context.Context
+	refreshCancel        context.CancelFunc
+
+	// Stale-if-error (optional)
+	grace *graceStore
+
+	// Probabilistic early expiration (optional)
+	earlyExpirationBeta float64
+	random              func() float64
+
+	// Distributed load lease (optional)
+	lease     Lease
+	leaseTTL  time.Duration
+	leaseWait time.Duration
+
+	// Write policy; behind queues origin writes under WriteBehind
+	writePolicy WritePolicy
+	behind      *writer.AsyncWriter
 }
 
 // ChainConfig holds configuration for Chain creation.
@@ -43,6 +78,74 @@ type ChainConfig struct {
 
 	// Logger for structured logging (optional, uses global if nil)
 	Logger *logging.Logger
+
+	// Codec is the codec of the chain's serialized layers. Typed converts the
+	// generic values they return into its type through it (optional,
+	// defaults to cache.JSONCodec)
+	Codec cache.Codec
+
+	// InvalidationBus broadcasts Set/Delete to other chain instances so they
+	// evict the key from their local layers (optional)
+	InvalidationBus InvalidationBus
+
+	// NodeID identifies this instance on the invalidation bus (optional, random if empty)
+	NodeID string
+
+	// LocalLayers is the number of leading layers that are private to this process
+	// and must be evicted on remote invalidations (optional, defaults to 1)
+	LocalLayers int
+
+	// StaleWhileRevalidate enables serving stale values (optional, disabled if 0).
+	// Values written with Set or loaded by GetOrLoad go stale after their TTL
+	// (soft TTL) and are kept for this much longer (hard TTL). Reads of a stale
+	// value return it immediately and trigger a single background refresh
+	// through the registered loader (see RegisterLoader).
+	StaleWhileRevalidate time.Duration
+
+	// StaleIfError enables serving the last known value when the chain can't
+	// (optional, disabled if 0). Values are remembered in a bounded in-process
+	// grace store for up to this long after they expire. Get falls back to it
+	// when every layer below L1 fails with a non-miss error (e.g. Redis down),
+	// and GetOrLoad also when the loader fails. GetWithMeta reports such values
+	// as stale.
+	StaleIfError time.Duration
+
+	// StaleIfErrorMaxKeys bounds the grace store (optional, defaults to 10000)
+	StaleIfErrorMaxKeys int
+
+	// EarlyExpirationBeta enables probabilistic early expiration (XFetch) to
+	// avoid expiry stampedes across instances (optional, disabled if 0).
+	// Values loaded by GetOrLoad or a registered loader remember how long the
+	// load took; each read then refreshes the value in the background with a
+	// probability that grows as expiry approaches and with the load time.
+	// 1.0 is a good default; higher values refresh earlier.
+	EarlyExpirationBeta float64
+
+	// Lease coordinates origin loads across instances (optional). Only the
+	// instance holding the lease for a key calls the loader; the others wait
+	// for the value to appear in a shared layer, or serve the grace value when
+	// StaleIfError is enabled.
+	Lease Lease
+
+	// LeaseTTL bounds how long a lease is held and should exceed the slowest
+	// load (optional, defaults to 10s). Values loaded after the lease expired
+	// are returned to the caller but not written to the layers.
+	LeaseTTL time.Duration
+
+	// LeaseWait is how long instances without the lease wait for the holder's
+	// value before loading by themselves (optional, defaults to LeaseTTL)
+	LeaseWait time.Duration
+
+	// WritePolicy selects how writes reach the last layer, which becomes the
+	// origin under any policy other than WriteAllLayers (optional, defaults to
+	// WriteAllLayers). Policies other than WriteAllLayers need at least two layers.
+	WritePolicy WritePolicy
+
+	// WriteBehind configures the queue applying origin writes under
+	// WriteBehind (optional, MaxAttempts defaults to 5). Set Journal to keep
+	// queued writes on disk across restarts. Writes the origin keeps
+	// rejecting are logged before reaching OnDeadLetter.
+	WriteBehind writer.AsyncWriterConfig
 }
 
 // New creates a new chain of cache layers with default configuration.
@@ -58,6 +161,9 @@ func NewWithConfig(config ChainConfig, layers ...cache.CacheLayer) (*Chain, erro
 	if len(layers) == 0 {
 		return nil, errors.New("chain: at least one layer required")
 	}
+	if config.WritePolicy != WriteAllLayers && len(layers) < 2 {
+		return nil, fmt.Errorf("chain: %s needs an origin layer below at least one cache", config.WritePolicy)
+	}
 
 	// Default to NoOpCollector if not provided
 	if config.Metrics == nil {
@@ -69,6 +175,10 @@ func NewWithConfig(config ChainConfig, layers ...cache.CacheLayer) (*Chain, erro
 		config.TTLStrategy = &UniformTTLStrategy{}
 	}
 
+	if config.Codec == nil {
+		config.Codec = cache.JSONCodec{}
+	}
+
 	// Set logger
 	logger := config.Logger
 	if logger == nil {
@@ -130,45 +240,381 @@ func NewWithConfig(config ChainConfig, layers ...cache.CacheLayer) (*Chain, erro
 		writers[i] = writer.NewAsyncWriterWithMetrics(layer, writerConfig, config.Metrics)
 	}
 
+	c := &Chain{
+		layers:               resilientLayers,
+		writers:              writers,
+		sf:                   &singleflight.Group{},
+		metrics:              config.Metrics,
+		ttlStrategy:          config.TTLStrategy,
+		codec:                config.Codec,
+		logger:               logger,
+		staleWhileRevalidate: config.StaleWhileRevalidate,
+		earlyExpirationBeta:  config.EarlyExpirationBeta,
+		random:               rand.Float64,
+		lease:                config.Lease,
+		leaseTTL:             config.LeaseTTL,
+		leaseWait:            config.LeaseWait,
+		writePolicy:          config.WritePolicy,
+	}
+	c.refreshCtx, c.refreshCancel = context.WithCancel(context.Background())
+
+	if c.leaseTTL <= 0 {
+		c.leaseTTL = 10 * time.Second
+	}
+	if c.leaseWait <= 0 {
+		c.leaseWait = c.leaseTTL
+	}
+
+	if config.StaleIfError > 0 {
+		c.grace = newGraceStore(config.StaleIfError, config.StaleIfErrorMaxKeys, logger)
+	}
+
+	if config.WritePolicy == WriteBehind {
+		behind, err := newWriteBehind(resilientLayers[len(resilientLayers)-1], config, logger)
+		if err != nil {
+			for _, w := range writers {
+				_ = w.Close()
+			}
+			return nil, fmt.Errorf("chain: failed to open write-behind queue: %w", err)
+		}
+		c.behind = behind
+	}
+
+	if config.InvalidationBus != nil {
+		if err := c.subscribeInvalidations(config); err != nil {
+			for _, w := range writers {
+				_ = w.Close()
+			}
+			if c.behind != nil {
+				_ = c.behind.Close()
+			}
+			return nil, fmt.Errorf("chain: failed to subscribe to invalidation bus: %w", err)
+		}
+	}
+
 	logger.Info("cache chain initialized successfully",
 		zap.Int("num_layers", len(resilientLayers)),
+		zap.String("write_policy", config.WritePolicy.String()),
 	)
 
-	return &Chain{
-		layers:      resilientLayers,
-		writers:     writers,
-		sf:          &singleflight.Group{},
-		metrics:     config.Metrics,
-		ttlStrategy: config.TTLStrategy,
-		logger:      logger,
-	}, nil
+	return c, nil
+}
+
+// subscribeInvalidations registers the chain on the invalidation bus.
+func (c *Chain) subscribeInvalidations(config ChainConfig) error {
+	c.bus = config.InvalidationBus
+	c.nodeID = config.NodeID
+	if c.nodeID == "" {
+		c.nodeID = uuid.NewString()
+	}
+	c.localLayers = config.LocalLayers
+	if c.localLayers <= 0 {
+		c.localLayers = 1
+	}
+	if c.localLayers > len(c.layers) {
+		c.localLayers = len(c.layers)
+	}
+
+	unsubscribe, err := c.bus.Subscribe(c.handleInvalidation)
+	if err != nil {
+		return err
+	}
+	c.unsubscribe = unsubscribe
+
+	c.logger.Info("subscribed to invalidation bus",
+		zap.String("node_id", c.nodeID),
+		zap.Int("local_layers", c.localLayers),
+	)
+
+	return nil
+}
+
+// handleInvalidation evicts keys changed by other nodes from the local layers.
+func (c *Chain) handleInvalidation(origin string, keys []string) {
+	// Our own writes already updated the local layers
+	if origin == c.nodeID {
+		return
+	}
+
+	ctx := context.Background()
+	for _, key := range keys {
+		c.grace.forget(key)
+		for i := 0; i < c.localLayers; i++ {
+			if err := c.layers[i].Delete(ctx, key); err != nil {
+				c.logger.Warn("failed to apply remote invalidation",
+					zap.String("key", key),
+					zap.String("origin", origin),
+					zap.String("layer_name", c.layers[i].Name()),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	c.logger.Debug("applied remote invalidation",
+		zap.String("origin", origin),
+		zap.Int("keys", len(keys)),
+	)
+}
+
+// publishInvalidation broadcasts changed keys to other nodes.
+// Failures are logged; peers fall back to TTL expiry.
+func (c *Chain) publishInvalidation(ctx context.Context, keys ...string) {
+	if c.bus == nil {
+		return
+	}
+
+	if err := c.bus.Publish(ctx, c.nodeID, keys...); err != nil {
+		c.logger.Warn("failed to publish invalidation",
+			zap.Strings("keys", keys),
+			zap.Error(err),
+		)
+	}
 }
 
 // Get retrieves a value from the chain.
 // It traverses layers in order until a hit, then synchronously warms upper layers.
 // Uses single-flight to prevent duplicate Gets for the same key.
 func (c *Chain) Get(ctx context.Context, key string) (interface{}, error) {
+	value, _, err := c.GetWithMeta(ctx, key)
+	return value, err
+}
+
+// GetMeta describes how a value returned by GetWithMeta was served.
+type GetMeta struct {
+	// Layer is the index of the layer that served the value,
+	// or -1 if it came from the grace store or the origin
+	Layer int
+
+	// Stale reports that the value is past its TTL: either within the
+	// stale-while-revalidate window, or served from the grace store
+	Stale bool
+
+	// Age is how long ago the value went stale (0 if not stale)
+	Age time.Duration
+
+	// Err is the error that caused a fallback to the grace store (nil otherwise)
+	Err error
+}
+
+// GetWithMeta is like Get but also reports where the value came from and
+// whether it is stale.
+func (c *Chain) GetWithMeta(ctx context.Context, key string) (interface{}, GetMeta, error) {
 	// Check context before single-flight
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, GetMeta{Layer: -1}, ctx.Err()
 	default:
 	}
 
 	// Use single-flight to prevent thundering herd
 	result, err, _ := c.sf.Do(key, func() (interface{}, error) {
-		return c.getWithFallback(ctx, key)
+		res, err := c.getWithFallback(ctx, key)
+		if err != nil {
+			if res.degraded {
+				if served, ok := c.grace.serve(key, err); ok {
+					return served, nil
+				}
+			}
+			return nil, err
+		}
+
+		if res.entry.IsStale() || c.shouldRefreshEarly(res.entry) {
+			if loader := c.loader.Load(); loader != nil {
+				c.refreshInBackground(key, *loader)
+			}
+		}
+
+		return res.served(), nil
 	})
+	if err != nil {
+		return nil, GetMeta{Layer: -1}, err
+	}
 
-	return result, err
+	s := result.(*served)
+	return s.value, s.meta, nil
+}
+
+// LoaderFunc loads a value from the origin (source of truth) after a full-chain miss.
+// It returns the value along with the TTL it should be cached with.
+type LoaderFunc func(ctx context.Context) (interface{}, time.Duration, error)
+
+// GetOrLoad retrieves a value from the chain, falling back to loader when no layer
+// can serve the key. The loaded value is written to every layer using the configured
+// TTLStrategy. Chain traversal and the load run inside a single single-flight call,
+// shared with Get, so concurrent callers for the same key trigger at most one
+// chain traversal and one origin load.
+func (c *Chain) GetOrLoad(ctx context.Context, key string, loader LoaderFunc) (interface{}, error) {
+	if loader == nil {
+		return nil, errors.New("chain: loader is required")
+	}
+
+	for {
+		// Check context before single-flight
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		result, err, _ := c.sf.Do(key, func() (interface{}, error) {
+			return c.getOrLoad(ctx, key, loader)
+		})
+		if err == nil {
+			return result.(*served).value, nil
+		}
+		if _, ok := result.(loadFailed); ok {
+			return nil, err
+		}
+		// Joined a Get that couldn't serve the key; load in our own flight
+	}
+}
+
+// loadFailed is the single-flight result of a GetOrLoad whose load failed,
+// so callers that joined it don't load again.
+type loadFailed struct{}
+
+// getOrLoad looks key up in the chain and loads it on a miss.
+func (c *Chain) getOrLoad(ctx context.Context, key string, loader LoaderFunc) (interface{}, error) {
+	res, err := c.getWithFallback(ctx, key)
+	if err == nil {
+		if res.entry.IsStale() || c.shouldRefreshEarly(res.entry) {
+			c.refreshInBackground(key, func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+				return loader(ctx)
+			})
+		}
+		return res.served(), nil
+	}
+
+	// Don't hit the origin on behalf of a caller that gave up
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	value, err := c.load(ctx, key, loader)
+	if err != nil {
+		if served, ok := c.grace.serve(key, err); ok {
+			return served, nil
+		}
+		return loadFailed{}, err
+	}
+	return &served{value: value, meta: GetMeta{Layer: -1}}, nil
+}
+
+// recordLoad reports an origin load if the metrics collector records them.
+func (c *Chain) recordLoad(success bool, duration time.Duration) {
+	if lm, ok := c.metrics.(metrics.LoadMetricsCollector); ok {
+		lm.RecordLoad(success, duration)
+	}
+}
+
+// load calls the loader and populates all layers with the result,
+// coordinating with other instances when a Lease is configured.
+func (c *Chain) load(ctx context.Context, key string, loader LoaderFunc) (interface{}, error) {
+	if c.lease != nil {
+		return c.loadWithLease(ctx, key, loader)
+	}
+	return c.loadAndStore(ctx, key, loader, 0)
+}
+
+// loadAndStore calls the loader and populates all layers with the result.
+// A failure to populate the layers is logged but doesn't fail the load.
+// A non-zero token is the lease fencing token: the layers are only populated
+// while it still holds the lease (see storeUnderLease).
+func (c *Chain) loadAndStore(ctx context.Context, key string, loader LoaderFunc, token uint64) (interface{}, error) {
+	start := time.Now()
+	value, ttl, err := loader(ctx)
+	duration := time.Since(start)
+	c.recordLoad(err == nil, duration)
+
+	if err != nil {
+		c.logger.Warn("origin load failed",
+			zap.String("key", key),
+			zap.Duration("duration", duration),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	c.logger.Debug("origin load completed",
+		zap.String("key", key),
+		zap.Duration("ttl", ttl),
+		zap.Duration("duration", duration),
+	)
+
+	// Record the load time for early expiration
+	update := c.setLayer(key, value, ttl)
+	if c.earlyExpirationBeta > 0 {
+		update = c.setEntryLayer(key, value, ttl, ttl+c.staleWhileRevalidate, duration)
+	}
+
+	if token != 0 {
+		var stored bool
+		stored, err = c.storeUnderLease(ctx, key, token, update)
+		if !stored {
+			c.logger.Warn("load lease lost before populating layers - skipping write",
+				zap.String("key", key),
+				zap.Duration("duration", duration),
+				zap.Error(err),
+			)
+			return value, nil
+		}
+	} else {
+		err = c.writeCaches(ctx, update)
+	}
+	c.stored(ctx, key, value, ttl+c.staleWhileRevalidate)
+	if err != nil {
+		c.logger.Warn("failed to populate layers after load",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+	}
+
+	return value, nil
+}
+
+// lookup is the result of a chain traversal.
+type lookup struct {
+	// entry is the value found, with metadata when the hit layer stores it
+	entry *cache.CacheEntry
+
+	// layer is the index of the hit layer
+	layer int
+
+	// degraded reports that the traversal failed because every layer below
+	// L1 (or L1 itself, in a single-layer chain) returned a non-miss error
+	degraded bool
+}
+
+// served returns the hit as a served value.
+func (l lookup) served() *served {
+	meta := GetMeta{Layer: l.layer}
+	if l.entry.IsStale() {
+		meta.Stale = true
+		meta.Age = time.Since(l.entry.StaleAt)
+	}
+	return &served{value: l.entry.Value, meta: meta}
+}
+
+// served is a value with the metadata describing how it was served.
+type served struct {
+	value interface{}
+	meta  GetMeta
 }
 
 // getWithFallback performs the actual chain traversal and warm-up.
-func (c *Chain) getWithFallback(ctx context.Context, key string) (interface{}, error) {
+func (c *Chain) getWithFallback(ctx context.Context, key string) (lookup, error) {
 	start := time.Now()
 	var lastErr error
 	hitLayer := -1
 
+	// Layers whose failure counts towards a degraded chain
+	firstDeep := 1
+	if len(c.layers) == 1 {
+		firstDeep = 0
+	}
+	degraded := true
+
 	c.logger.Debug("chain get started",
 		zap.String("key", key),
 	)
@@ -199,11 +645,11 @@ func (c *Chain) getWithFallback(ctx context.Context, key string) (interface{}, e
 		// Check for context cancellation
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return lookup{layer: -1}, ctx.Err()
 		default:
 		}
 
-		value, err := layer.Get(ctx, key)
+		entry, err := getEntry(ctx, layer, key)
 		if err != nil {
 			// Check if it's a "not found" error - continue to next layer
 			if cache.IsNotFound(err) {
@@ -212,6 +658,9 @@ func (c *Chain) getWithFallback(ctx context.Context, key string) (interface{}, e
 					zap.Int("layer_index", i),
 					zap.String("layer_name", layer.Name()),
 				)
+				if i >= firstDeep {
+					degraded = false
+				}
 				lastErr = err
 				continue
 			}
@@ -226,86 +675,132 @@ func (c *Chain) getWithFallback(ctx context.Context, key string) (interface{}, e
 			continue
 		}
 
-		// Hit! Warm up upper layers synchronously
+		// Hit! Warm up upper layers asynchronously
 		hitLayer = i
 		if i > 0 {
-			c.warmUpperLayers(ctx, key, value, i)
+			c.warmUpperLayers(ctx, entry, i)
+			c.grace.remember(entry)
 		}
 
-		return value, nil
+		return lookup{entry: entry, layer: i}, nil
 	}
 
 	// All layers missed
 	if lastErr != nil {
-		return nil, lastErr
+		return lookup{layer: -1, degraded: degraded}, lastErr
 	}
-	return nil, cache.ErrKeyNotFound
+	return lookup{layer: -1}, cache.ErrKeyNotFound
+}
+
+// layerTTL returns the TTL for key in layer i according to the TTLStrategy.
+func (c *Chain) layerTTL(i int, key string, baseTTL time.Duration) time.Duration {
+	return ttlFor(c.ttlStrategy, TTLContext{
+		LayerIndex: i,
+		LayerCount: len(c.layers),
+		LayerName:  c.layers[i].Name(),
+		Key:        key,
+	}, baseTTL)
 }
 
+// defaultWarmUpTTL is the base TTL for warming values whose remaining
+// lifetime the hit layer can't report.
+const defaultWarmUpTTL = time.Hour
+
 // warmUpperLayers asynchronously warms all layers above the hit layer.
-func (c *Chain) warmUpperLayers(ctx context.Context, key string, value interface{}, hitIndex int) {
-	// Warm up from hit layer up to L1
-	// Use a reasonable default TTL for warm-up (1 hour)
-	baseTTL := time.Hour
+// Each layer's TTL comes from the TTLStrategy applied to the entry's remaining
+// lifetime, capped so promoted copies never outlive the source. Entries carrying
+// a stale time keep it, capped at the new expiry.
+func (c *Chain) warmUpperLayers(ctx context.Context, entry *cache.CacheEntry, hitIndex int) {
+	now := time.Now()
+	remaining := entry.ExpiresAt.Sub(now)
+	if !entry.ExpiresAt.IsZero() && remaining <= 0 {
+		return
+	}
 
 	c.logger.Debug("warming upper layers",
-		zap.String("key", key),
+		zap.String("key", entry.Key),
 		zap.Int("hit_layer", hitIndex),
 		zap.Int("layers_to_warm", hitIndex),
+		zap.Duration("remaining_ttl", remaining),
 	)
 
 	for i := hitIndex - 1; i >= 0; i-- {
-		// Calculate TTL for this layer using strategy
-		ttl := c.ttlStrategy.GetTTL(i, baseTTL)
+		promoted := *entry
+		if entry.ExpiresAt.IsZero() {
+			promoted.ExpiresAt = now.Add(c.layerTTL(i, entry.Key, defaultWarmUpTTL))
+		} else if ttl := c.layerTTL(i, entry.Key, remaining); ttl > 0 && ttl < remaining {
+			promoted.ExpiresAt = now.Add(ttl)
+		}
+		if promoted.StaleAt.After(promoted.ExpiresAt) {
+			promoted.StaleAt = promoted.ExpiresAt
+		}
 
 		// Use async writer instead of direct Set() - non-blocking
 		// Errors are tracked internally by AsyncWriter
-		_ = c.writers[i].Write(ctx, key, value, ttl)
+		_ = c.writers[i].WriteEntry(ctx, &promoted)
 	}
 }
 
 // Set writes the value to all layers in the chain.
 // If any layer fails, the error is returned but other layers are still attempted.
 // The TTL is adjusted per layer using the configured TTLStrategy.
+// With StaleWhileRevalidate enabled, ttl is the soft TTL (see SetWithSoftTTL).
+// With a WritePolicy, the origin is written as the policy dictates.
+// If an InvalidationBus is configured, other instances are told to evict the key.
 func (c *Chain) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	var lastErr error
-
-	for i, layer := range c.layers {
-		// Check for context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Calculate TTL for this layer using strategy
-		layerTTL := c.ttlStrategy.GetTTL(i, ttl)
+	return c.set(ctx, key, value, ttl+c.staleWhileRevalidate, c.setLayer(key, value, ttl))
+}
 
-		if err := layer.Set(ctx, key, value, layerTTL); err != nil {
-			lastErr = err
-			// Continue to set other layers even if one fails
-		}
+// set applies update for key to the layers according to the write policy,
+// then records the change (see stored) unless the origin rejected it.
+func (c *Chain) set(ctx context.Context, key string, value interface{}, hardTTL time.Duration, update layerWrite) error {
+	err := c.write(ctx, []string{key}, update)
+	if errors.Is(err, ErrOriginWrite) {
+		return err
 	}
+	c.stored(ctx, key, value, hardTTL)
+	return err
+}
 
-	return lastErr
+// stored remembers a written value for stale-if-error and tells other
+// instances to evict their copy.
+func (c *Chain) stored(ctx context.Context, key string, value interface{}, hardTTL time.Duration) {
+	c.grace.remember(&cache.CacheEntry{Key: key, Value: value, ExpiresAt: time.Now().Add(hardTTL)})
+	c.publishInvalidation(ctx, key)
 }
 
 // Delete removes the key from all layers in the chain.
 // If any layer fails, the error is returned but other layers are still attempted.
+// With a WritePolicy, the origin is written as the policy dictates.
+// If an InvalidationBus is configured, other instances are told to evict the key.
 func (c *Chain) Delete(ctx context.Context, key string) error {
+	err := c.write(ctx, []string{key}, func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		return layer.Delete(ctx, key)
+	})
+	if errors.Is(err, ErrOriginWrite) {
+		return err
+	}
+
+	c.grace.forget(key)
+	c.publishInvalidation(ctx, key)
+
+	return err
+}
+
+// Flush waits until the writes the chain queued were applied: warm-ups of
+// the upper layers and, under WriteBehind, the origin writes. If ctx is done
+// first, an error wrapping writer.ErrFlushTimeout is returned.
+func (c *Chain) Flush(ctx context.Context) error {
 	var lastErr error
 
-	for _, layer := range c.layers {
-		// Check for context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	for _, w := range c.writers {
+		if err := w.Flush(ctx); err != nil {
+			lastErr = err
 		}
-
-		if err := layer.Delete(ctx, key); err != nil {
+	}
+	if c.behind != nil {
+		if err := c.behind.Flush(ctx); err != nil {
 			lastErr = err
-			// Continue to delete from other layers even if one fails
 		}
 	}
 
@@ -317,12 +812,28 @@ func (c *Chain) Delete(ctx context.Context, key string) error {
 func (c *Chain) Close() error {
 	var lastErr error
 
-	// Close async writers first
+	// Stop receiving remote invalidations
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+	}
+
+	// Cancel and wait for background refreshes
+	c.refreshCancel()
+	c.refreshWG.Wait()
+
+	c.grace.close()
+
+	// Close async writers first, draining pending origin writes
 	for _, w := range c.writers {
 		if err := w.Close(); err != nil {
 			lastErr = err
 		}
 	}
+	if c.behind != nil {
+		if err := c.behind.Close(); err != nil {
+			lastErr = err
+		}
+	}
 
 	// Then close layers
 	for _, layer := range c.layers {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -478,6 +479,234 @@ func TestChain_String(t *testing.T) {
 	}
 }
 
+func TestChain_GetOrLoad_Hit(t *testing.T) {
+	l1 := mock.NewMockLayer("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		return "cached", nil
+	}
+
+	chain, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	value, err := chain.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		t.Error("Loader should not be called on hit")
+		return nil, 0, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if value != "cached" {
+		t.Errorf("Expected 'cached', got %v", value)
+	}
+}
+
+func TestChain_GetOrLoad_MissPopulatesLayers(t *testing.T) {
+	var mu sync.Mutex
+	setTTLs := make(map[string]time.Duration)
+
+	newLayer := func(name string) *mock.MockLayer {
+		l := mock.NewMockLayerWithDefaults(name)
+		l.SetFunc = func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			if value != "loaded" {
+				t.Errorf("%s: expected 'loaded', got %v", name, value)
+			}
+			mu.Lock()
+			setTTLs[name] = ttl
+			mu.Unlock()
+			return nil
+		}
+		return l
+	}
+	l1 := newLayer("L1")
+	l2 := newLayer("L2")
+
+	chain, err := NewWithConfig(ChainConfig{
+		TTLStrategy: &CustomTTLStrategy{TTLs: []time.Duration{time.Minute}},
+	}, l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	value, err := chain.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "loaded", time.Hour, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if value != "loaded" {
+		t.Errorf("Expected 'loaded', got %v", value)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if setTTLs["L1"] != time.Minute {
+		t.Errorf("L1: expected TTL %v from strategy, got %v", time.Minute, setTTLs["L1"])
+	}
+	if setTTLs["L2"] != time.Hour {
+		t.Errorf("L2: expected TTL %v from loader, got %v", time.Hour, setTTLs["L2"])
+	}
+}
+
+func TestChain_GetOrLoad_LoaderError(t *testing.T) {
+	l1 := mock.NewMockLayerWithDefaults("L1")
+
+	chain, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	loadErr := errors.New("database down")
+	_, err = chain.GetOrLoad(context.Background(), "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return nil, 0, loadErr
+	})
+	if !errors.Is(err, loadErr) {
+		t.Errorf("Expected loader error, got %v", err)
+	}
+
+	if l1.SetCalls() != 0 {
+		t.Errorf("Layers should not be populated on load failure, got %d sets", l1.SetCalls())
+	}
+}
+
+func TestChain_GetOrLoad_SingleFlight(t *testing.T) {
+	l1 := mock.NewMockLayerWithDefaults("L1")
+
+	chain, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	var loads int64
+	var mu sync.Mutex
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		mu.Lock()
+		loads++
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		return "loaded", time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := chain.GetOrLoad(context.Background(), "key", loader)
+			if err != nil {
+				t.Errorf("GetOrLoad failed: %v", err)
+				return
+			}
+			if value != "loaded" {
+				t.Errorf("Expected 'loaded', got %v", value)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if loads != 1 {
+		t.Errorf("Expected 1 origin load, got %d", loads)
+	}
+}
+
+func TestChain_GetOrLoad_SharesFlightWithGet(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	l1 := mock.NewMockLayerWithDefaults("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, cache.ErrKeyNotFound
+	}
+
+	chain, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	var loads int64
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		atomic.AddInt64(&loads, 1)
+		return "loaded", time.Minute, nil
+	}
+
+	// GetOrLoad starts the flight; Get joins it and gets the loaded value
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if value, err := chain.GetOrLoad(context.Background(), "key", loader); err != nil || value != "loaded" {
+			t.Errorf("Expected 'loaded', got %v (%v)", value, err)
+		}
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		if value, err := chain.Get(context.Background(), "key"); err != nil || value != "loaded" {
+			t.Errorf("Expected Get to share the load, got %v (%v)", value, err)
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls := l1.GetCalls(); calls != 1 {
+		t.Errorf("Expected 1 layer read, got %d", calls)
+	}
+	if n := atomic.LoadInt64(&loads); n != 1 {
+		t.Errorf("Expected 1 origin load, got %d", n)
+	}
+}
+
+func TestChain_GetOrLoad_JoinsMissingGet(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	l1 := mock.NewMockLayerWithDefaults("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, cache.ErrKeyNotFound
+	}
+
+	chain, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "loaded", time.Minute, nil
+	}
+
+	// A plain Get starts the flight and misses; GetOrLoad must still load
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := chain.Get(context.Background(), "key"); err == nil {
+			t.Error("Expected Get to miss")
+		}
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		if value, err := chain.GetOrLoad(context.Background(), "key", loader); err != nil || value != "loaded" {
+			t.Errorf("Expected 'loaded', got %v (%v)", value, err)
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
 func BenchmarkChain_Get_L1Hit(b *testing.B) {
 	l1 := mock.NewMockLayer("L1")
 	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
@@ -83,10 +83,17 @@ func TestChain_WithDecayingTTLStrategy(t *testing.T) {
 		}
 	}
 
-	// Log the TTLs used
-	t.Logf("L0 TTL: %v", strategy.GetTTL(0, baseTTL))
-	t.Logf("L1 TTL: %v", strategy.GetTTL(1, baseTTL))
-	t.Logf("L2 TTL: %v", strategy.GetTTL(2, baseTTL))
+	// Each layer gets half the TTL of the next
+	expected := []time.Duration{2 * time.Hour, 4 * time.Hour, 8 * time.Hour}
+	for i, layer := range []*memory.MemoryCache{l1, l2, l3} {
+		entry, err := layer.GetEntry(ctx, "key1")
+		if err != nil {
+			t.Fatalf("Layer %d: GetEntry failed: %v", i, err)
+		}
+		if ttl := entry.TimeToLive(); ttl > expected[i] || ttl < expected[i]-time.Minute {
+			t.Errorf("Layer %d: expected ~%v TTL, got %v", i, expected[i], ttl)
+		}
+	}
 }
 
 func TestChain_WithCustomTTLStrategy(t *testing.T) {
@@ -293,3 +300,52 @@ func TestChain_TTLStrategyWithExpiration(t *testing.T) {
 		t.Errorf("L1: expected value1, got %v", val1)
 	}
 }
+
+func TestChain_WarmupCapsTTLToSourceLifetime(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1", MaxSize: 100})
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2", MaxSize: 100})
+	l3 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L3", MaxSize: 100})
+
+	// The strategy asks for longer TTLs than the source has left
+	strategy := &CustomTTLStrategy{
+		TTLs: []time.Duration{
+			10 * time.Second,
+			5 * time.Minute,
+		},
+	}
+	c, err := NewWithConfig(ChainConfig{TTLStrategy: strategy}, l1, l2, l3)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+
+	if err := l3.Set(ctx, "key1", "value1", 30*time.Second); err != nil {
+		t.Fatalf("Set in L3 failed: %v", err)
+	}
+
+	if _, err := c.Get(ctx, "key1"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	c.Flush(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	// L1 gets the strategy's shorter TTL
+	e1, err := l1.GetEntry(ctx, "key1")
+	if err != nil {
+		t.Fatalf("L1 not warmed up: %v", err)
+	}
+	if ttl := e1.TimeToLive(); ttl > 10*time.Second || ttl < 9*time.Second {
+		t.Errorf("L1: expected ~10s TTL, got %v", ttl)
+	}
+
+	// L2 is capped at the source's remaining 30s instead of 5 minutes
+	e2, err := l2.GetEntry(ctx, "key1")
+	if err != nil {
+		t.Fatalf("L2 not warmed up: %v", err)
+	}
+	if ttl := e2.TimeToLive(); ttl > 30*time.Second || ttl < 29*time.Second {
+		t.Errorf("L2: expected ~30s TTL, got %v", ttl)
+	}
+}
@@ -0,0 +1,117 @@
+package chain
+
+import (
+	"context"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/logging"
+
+	"go.uber.org/zap"
+)
+
+// graceStore remembers the last known value of keys so they can still be
+// served, marked stale, when the chain fails. It is process-local and
+// bounded; entries are kept until maxStaleness after their expiry.
+// All methods are no-ops on a nil store, which is used when stale-if-error
+// is disabled.
+type graceStore struct {
+	values       *memory.MemoryCache
+	maxStaleness time.Duration
+	logger       *logging.Logger
+}
+
+func newGraceStore(maxStaleness time.Duration, maxKeys int, logger *logging.Logger) *graceStore {
+	if maxKeys <= 0 {
+		maxKeys = 10000
+	}
+
+	return &graceStore{
+		values: memory.NewMemoryCache(memory.MemoryCacheConfig{
+			Name:    "grace",
+			MaxSize: maxKeys,
+			Logger:  logger,
+		}),
+		maxStaleness: maxStaleness,
+		logger:       logger.Named("grace"),
+	}
+}
+
+// remember records the value of an entry. Its expiry marks when it goes
+// stale; entries with unknown expiry are considered stale from now.
+func (g *graceStore) remember(entry *cache.CacheEntry) {
+	if g == nil {
+		return
+	}
+
+	staleAt := entry.ExpiresAt
+	if staleAt.IsZero() {
+		staleAt = time.Now()
+	}
+
+	_ = g.values.SetEntry(context.Background(), &cache.CacheEntry{
+		Key:       entry.Key,
+		Value:     entry.Value,
+		StaleAt:   staleAt,
+		ExpiresAt: staleAt.Add(g.maxStaleness),
+	})
+}
+
+// forget drops a key, so deleted values are never served.
+func (g *graceStore) forget(key string) {
+	if g == nil {
+		return
+	}
+	_ = g.values.Delete(context.Background(), key)
+}
+
+// forgetPrefix drops the keys starting with prefix.
+func (g *graceStore) forgetPrefix(prefix string) {
+	if g == nil {
+		return
+	}
+	_, _ = g.values.DeletePrefix(context.Background(), prefix, nil)
+}
+
+// serve returns the last known value of key as a stale value, if it's
+// within the maximum staleness. cause is the error that made the chain fail.
+func (g *graceStore) serve(key string, cause error) (*served, bool) {
+	if g == nil {
+		return nil, false
+	}
+
+	entry, err := g.values.GetEntry(context.Background(), key)
+	if err != nil {
+		return nil, false
+	}
+
+	age := time.Since(entry.StaleAt)
+	if age < 0 {
+		age = 0
+	}
+
+	g.logger.Warn("serving stale value after chain failure",
+		zap.String("key", key),
+		zap.Duration("age", age),
+		zap.Error(cause),
+	)
+
+	return &served{
+		value: entry.Value,
+		meta: GetMeta{
+			Layer: -1,
+			Stale: true,
+			Age:   age,
+			Err:   cause,
+		},
+	}, true
+}
+
+// close releases the store.
+func (g *graceStore) close() {
+	if g == nil {
+		return
+	}
+	_ = g.values.Close()
+}
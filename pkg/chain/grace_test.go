@@ -0,0 +1,130 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
+)
+
+// newDegradedChain builds an L1 memory + failing L2 chain.
+// l2Err is what L2 returns from Get; Sets succeed.
+func newDegradedChain(t *testing.T, config ChainConfig, l2Err error) *Chain {
+	t.Helper()
+
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := mock.NewMockLayerWithDefaults("L2")
+	l2.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		return nil, l2Err
+	}
+
+	c, err := NewWithConfig(config, l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestChain_StaleIfError_ServesLastKnownValue(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{StaleIfError: time.Minute}, cache.ErrLayerUnavailable)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "key", "value", 20*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	value, meta, err := c.GetWithMeta(ctx, "key")
+	if err != nil {
+		t.Fatalf("Expected stale value, got error: %v", err)
+	}
+	if value != "value" {
+		t.Errorf("Expected 'value', got %v", value)
+	}
+	if !meta.Stale || meta.Layer != -1 || meta.Age <= 0 {
+		t.Errorf("Expected stale metadata from grace store, got %+v", meta)
+	}
+	if !errors.Is(meta.Err, cache.ErrLayerUnavailable) {
+		t.Errorf("Expected cause ErrLayerUnavailable, got %v", meta.Err)
+	}
+
+	// Plain Get serves it too
+	if value, err := c.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("Expected Get to serve stale value, got %v (err: %v)", value, err)
+	}
+}
+
+func TestChain_StaleIfError_MaxStaleness(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{StaleIfError: 20 * time.Millisecond}, cache.ErrLayerUnavailable)
+	ctx := context.Background()
+
+	c.Set(ctx, "key", "value", 10*time.Millisecond)
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, cache.ErrLayerUnavailable) {
+		t.Errorf("Expected layer error past max staleness, got %v", err)
+	}
+}
+
+func TestChain_StaleIfError_NotOnMiss(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{StaleIfError: time.Minute}, cache.ErrKeyNotFound)
+	ctx := context.Background()
+
+	c.Set(ctx, "key", "value", 10*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// L2 answered with a clean miss: the value is really gone
+	if _, err := c.Get(ctx, "key"); !cache.IsNotFound(err) {
+		t.Errorf("Expected miss, got %v", err)
+	}
+}
+
+func TestChain_StaleIfError_DeleteForgets(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{StaleIfError: time.Minute}, cache.ErrLayerUnavailable)
+	ctx := context.Background()
+
+	c.Set(ctx, "key", "value", time.Minute)
+	c.Delete(ctx, "key")
+
+	if _, err := c.Get(ctx, "key"); err == nil {
+		t.Error("Expected deleted value not to be served")
+	}
+}
+
+func TestChain_StaleIfError_GetOrLoad(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{StaleIfError: time.Minute}, cache.ErrKeyNotFound)
+	ctx := context.Background()
+
+	c.Set(ctx, "key", "value", 10*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Origin is down: the last known value is served
+	value, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return nil, 0, errors.New("origin down")
+	})
+	if err != nil || value != "value" {
+		t.Errorf("Expected stale value, got %v (err: %v)", value, err)
+	}
+}
+
+func TestChain_StaleIfError_Disabled(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{}, cache.ErrLayerUnavailable)
+	ctx := context.Background()
+
+	c.Set(ctx, "key", "value", 10*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "key"); err == nil {
+		t.Error("Expected error without StaleIfError")
+	}
+}
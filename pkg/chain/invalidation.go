@@ -0,0 +1,98 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBusClosed is returned when publishing to or subscribing on a closed bus.
+var ErrBusClosed = errors.New("chain: invalidation bus closed")
+
+// InvalidationBus broadcasts key invalidations between chain instances.
+// When one instance writes or deletes a key, the others evict it from their
+// process-local layers (e.g. memory L1) so they stop serving the old value.
+type InvalidationBus interface {
+	// Publish broadcasts that keys were changed by the node identified by origin.
+	Publish(ctx context.Context, origin string, keys ...string) error
+
+	// Subscribe registers handler for invalidations published by any node,
+	// including the subscriber itself. The returned function unsubscribes.
+	Subscribe(handler func(origin string, keys []string)) (func(), error)
+
+	// Close stops delivering invalidations and releases resources.
+	Close() error
+}
+
+// LocalInvalidationBus is an in-process InvalidationBus.
+// It connects chains living in the same process, which is mostly useful for tests.
+// Handlers are called synchronously from Publish.
+type LocalInvalidationBus struct {
+	mu       sync.RWMutex
+	handlers map[int]func(origin string, keys []string)
+	nextID   int
+	closed   bool
+}
+
+// NewLocalInvalidationBus creates a new in-process invalidation bus.
+func NewLocalInvalidationBus() *LocalInvalidationBus {
+	return &LocalInvalidationBus{
+		handlers: make(map[int]func(origin string, keys []string)),
+	}
+}
+
+// Publish delivers the invalidation to all subscribers.
+func (b *LocalInvalidationBus) Publish(ctx context.Context, origin string, keys ...string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	handlers := make([]func(origin string, keys []string), 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(origin, keys)
+	}
+
+	return nil
+}
+
+// Subscribe registers a handler for all published invalidations.
+func (b *LocalInvalidationBus) Subscribe(handler func(origin string, keys []string)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+// Close removes all subscribers and rejects further publishes.
+func (b *LocalInvalidationBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.handlers = make(map[int]func(origin string, keys []string))
+	return nil
+}
@@ -0,0 +1,122 @@
+package chain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/redis"
+)
+
+// Both bus implementations must satisfy the interface
+var (
+	_ InvalidationBus = (*LocalInvalidationBus)(nil)
+	_ InvalidationBus = (*redis.InvalidationBus)(nil)
+)
+
+func TestChain_InvalidationBus_EvictsPeers(t *testing.T) {
+	bus := NewLocalInvalidationBus()
+	defer bus.Close()
+
+	l1a := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1-a"})
+	l1b := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1-b"})
+
+	a, err := NewWithConfig(ChainConfig{InvalidationBus: bus, NodeID: "a"}, l1a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	b, err := NewWithConfig(ChainConfig{InvalidationBus: bus, NodeID: "b"}, l1b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	ctx := context.Background()
+
+	if err := b.Set(ctx, "key", "old", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Node a writes a new value: b must drop its local copy
+	if err := a.Set(ctx, "key", "new", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if _, err := b.Get(ctx, "key"); !cache.IsNotFound(err) {
+		t.Errorf("Expected peer L1 to be invalidated, got err=%v", err)
+	}
+
+	// Node a ignores its own invalidation
+	value, err := a.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value != "new" {
+		t.Errorf("Expected 'new', got %v", value)
+	}
+}
+
+func TestChain_InvalidationBus_Delete(t *testing.T) {
+	bus := NewLocalInvalidationBus()
+	defer bus.Close()
+
+	l1a := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1-a"})
+	l1b := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1-b"})
+
+	a, err := NewWithConfig(ChainConfig{InvalidationBus: bus}, l1a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	b, err := NewWithConfig(ChainConfig{InvalidationBus: bus}, l1b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	ctx := context.Background()
+
+	// Populate b directly so no invalidation reaches a
+	if err := l1b.Set(ctx, "key", "value", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if err := a.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if _, err := b.Get(ctx, "key"); !cache.IsNotFound(err) {
+		t.Errorf("Expected peer L1 to be invalidated, got err=%v", err)
+	}
+}
+
+func TestLocalInvalidationBus_Unsubscribe(t *testing.T) {
+	bus := NewLocalInvalidationBus()
+
+	calls := 0
+	unsubscribe, err := bus.Subscribe(func(origin string, keys []string) {
+		calls++
+	})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	ctx := context.Background()
+	_ = bus.Publish(ctx, "node", "k1")
+	unsubscribe()
+	_ = bus.Publish(ctx, "node", "k2")
+
+	if calls != 1 {
+		t.Errorf("Expected 1 delivery, got %d", calls)
+	}
+
+	bus.Close()
+	if err := bus.Publish(ctx, "node", "k3"); err != ErrBusClosed {
+		t.Errorf("Expected ErrBusClosed, got %v", err)
+	}
+}
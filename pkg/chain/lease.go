@@ -0,0 +1,299 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"cache-chain/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// errLeaseHeld is the cause reported for grace values served while another
+// instance holds the load lease.
+var errLeaseHeld = errors.New("chain: load lease held by another instance")
+
+// leasePollInterval is how often instances waiting on a lease check whether
+// the holder has populated the cache.
+const leasePollInterval = 20 * time.Millisecond
+
+// Lease coordinates origin loads across chain instances. Single-flight only
+// coalesces misses within a process; with a lease, only the instance holding
+// it for a key calls the loader while the others wait for the value to show
+// up in a shared layer.
+//
+// Every acquisition returns a fencing token unique to that holder, and the
+// loaded value is only written while the token still holds the lease, so a
+// loader that outlived its lease doesn't overwrite the value written by the
+// next holder. With a FencedLease, layers implementing cache.FencedLayer
+// check the token and write in one atomic step; other layers are written
+// after checking the token with Holds.
+type Lease interface {
+	// Acquire takes the lease on key for ttl if nobody holds it.
+	// It returns the fencing token and whether the lease was acquired.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (token uint64, acquired bool, err error)
+
+	// Release gives up the lease if token still holds it.
+	Release(ctx context.Context, key string, token uint64) error
+
+	// Holds reports whether token still holds the lease on key.
+	Holds(ctx context.Context, key string, token uint64) (bool, error)
+}
+
+// FencedLease is a Lease stored in a shared layer, which can check a token
+// atomically with a write (see cache.FencedLayer).
+type FencedLease interface {
+	Lease
+
+	// FenceKey returns the key of the layer holding the lease on key.
+	FenceKey(key string) string
+}
+
+// LocalLease is an in-process Lease.
+// It coordinates chains living in the same process, which is mostly useful for tests.
+type LocalLease struct {
+	mu     sync.Mutex
+	leases map[string]localLease
+	tokens uint64
+}
+
+type localLease struct {
+	token     uint64
+	expiresAt time.Time
+}
+
+// NewLocalLease creates a new in-process lease.
+func NewLocalLease() *LocalLease {
+	return &LocalLease{
+		leases: make(map[string]localLease),
+	}
+}
+
+// Acquire takes the lease on key unless an unexpired lease exists.
+func (l *LocalLease) Acquire(ctx context.Context, key string, ttl time.Duration) (uint64, bool, error) {
+	select {
+	case <-ctx.Done():
+		return 0, false, ctx.Err()
+	default:
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if held, ok := l.leases[key]; ok && now.Before(held.expiresAt) {
+		return 0, false, nil
+	}
+
+	l.tokens++
+	l.leases[key] = localLease{token: l.tokens, expiresAt: now.Add(ttl)}
+	return l.tokens, true, nil
+}
+
+// Release removes the lease if token holds it.
+func (l *LocalLease) Release(ctx context.Context, key string, token uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if held, ok := l.leases[key]; ok && held.token == token {
+		delete(l.leases, key)
+	}
+	return nil
+}
+
+// Holds reports whether token holds an unexpired lease on key.
+func (l *LocalLease) Holds(ctx context.Context, key string, token uint64) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held, ok := l.leases[key]
+	return ok && held.token == token && time.Now().Before(held.expiresAt), nil
+}
+
+// loadWithLease loads key from the origin only if this instance gets the
+// lease. Otherwise it waits up to leaseWait for the holder to populate the
+// cache, serving the grace value meanwhile if there is one. If the holder
+// gives the lease up without populating the cache, the lease is taken over;
+// if it doesn't deliver in time, the key is loaded without the lease. Lease
+// errors fall back to an uncoordinated load.
+func (c *Chain) loadWithLease(ctx context.Context, key string, loader LoaderFunc) (interface{}, error) {
+	token, acquired, err := c.lease.Acquire(ctx, key, c.leaseTTL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.logger.Warn("failed to acquire load lease - loading without it",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return c.loadAndStore(ctx, key, loader, 0)
+	}
+
+	if !acquired {
+		if served, ok := c.grace.serve(key, errLeaseHeld); ok {
+			return served.value, nil
+		}
+
+		var entry *cache.CacheEntry
+		entry, token, err = c.waitForLeaseHolder(ctx, key)
+		if entry != nil {
+			return entry.Value, nil
+		}
+		if token == 0 {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.logger.Warn("load lease holder did not populate the cache in time - loading",
+				zap.String("key", key),
+				zap.Duration("wait", c.leaseWait),
+				zap.Error(err),
+			)
+			return c.loadAndStore(ctx, key, loader, 0)
+		}
+	}
+
+	// Release even if the caller gave up, so others don't wait for the TTL
+	defer c.releaseLease(context.WithoutCancel(ctx), key, token)
+	return c.loadAndStore(ctx, key, loader, token)
+}
+
+// releaseLease gives up a lease taken by this instance.
+func (c *Chain) releaseLease(ctx context.Context, key string, token uint64) {
+	if err := c.lease.Release(ctx, key, token); err != nil {
+		c.logger.Warn("failed to release load lease",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+	}
+}
+
+// waitForLeaseHolder polls the layers until the key shows up or leaseWait
+// elapses. If the lease is given up before the key shows up, e.g. because
+// the holder's load failed, it is taken over and its token returned instead.
+func (c *Chain) waitForLeaseHolder(ctx context.Context, key string) (*cache.CacheEntry, uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.leaseWait)
+	defer cancel()
+
+	ticker := time.NewTicker(leasePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-ticker.C:
+		}
+
+		if entry, ok := c.peek(ctx, key); ok {
+			return entry, 0, nil
+		}
+
+		token, acquired, err := c.lease.Acquire(ctx, key, c.leaseTTL)
+		if err != nil || !acquired {
+			continue
+		}
+		// The holder may have populated the cache right before releasing
+		if entry, ok := c.peek(ctx, key); ok {
+			c.releaseLease(context.WithoutCancel(ctx), key, token)
+			return entry, 0, nil
+		}
+		return nil, token, nil
+	}
+}
+
+// peek looks key up for pollers. Unlike getWithFallback it records no
+// metrics, logs nothing and doesn't warm upper layers.
+func (c *Chain) peek(ctx context.Context, key string) (*cache.CacheEntry, bool) {
+	for _, layer := range c.layers {
+		var entry *cache.CacheEntry
+		var err error
+		if p, ok := layer.(entryPeeker); ok {
+			entry, err = p.PeekEntry(ctx, key)
+		} else {
+			entry, err = getEntry(ctx, layer, key)
+		}
+		if err == nil {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// entryPeeker is implemented by layers that can be read without leaving a
+// trace in metrics and logs, like resilience.ResilientLayer.
+type entryPeeker interface {
+	PeekEntry(ctx context.Context, key string) (*cache.CacheEntry, error)
+}
+
+// storeUnderLease populates the layers with a value loaded under the lease
+// token and reports whether it did. Layers implementing cache.FencedLayer,
+// with a FencedLease, check the token and write in one step; they are
+// written first, deepest first, and nothing else is written if one of them
+// refuses the write. Without such a layer, the token is checked with Holds
+// before writing.
+func (c *Chain) storeUnderLease(ctx context.Context, key string, token uint64, update layerWrite) (bool, error) {
+	layers := c.cacheLayers()
+	written := make([]bool, len(layers))
+	fenced := false
+
+	if fl, ok := c.lease.(FencedLease); ok {
+		fenceKey := fl.FenceKey(key)
+		for i := len(layers) - 1; i >= 0; i-- {
+			f, ok := layers[i].(cache.FencedLayer)
+			if !ok {
+				continue
+			}
+			w := &fencedWrite{FencedLayer: f, fenceKey: fenceKey, token: token}
+			err := update(ctx, i, w)
+			if errors.Is(err, cache.ErrNotSupported) {
+				continue
+			}
+			if err != nil || !w.stored {
+				return false, err
+			}
+			written[i] = true
+			fenced = true
+		}
+	}
+
+	if !fenced {
+		if held, err := c.lease.Holds(ctx, key, token); err != nil || !held {
+			return false, err
+		}
+	}
+
+	return true, c.writeCaches(ctx, func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		if written[i] {
+			return nil
+		}
+		return update(ctx, i, layer)
+	})
+}
+
+// fencedWrite turns the writes of a layerWrite into fenced writes.
+type fencedWrite struct {
+	cache.FencedLayer
+	fenceKey string
+	token    uint64
+	stored   bool
+}
+
+func (f *fencedWrite) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	entry := &cache.CacheEntry{Key: key, Value: value}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	return f.SetEntry(ctx, entry)
+}
+
+func (f *fencedWrite) SetEntry(ctx context.Context, entry *cache.CacheEntry) error {
+	stored, err := f.SetEntryFenced(ctx, entry, f.fenceKey, f.token)
+	f.stored = stored
+	return err
+}
+
+func (f *fencedWrite) GetEntry(ctx context.Context, key string) (*cache.CacheEntry, error) {
+	return nil, cache.ErrNotSupported
+}
@@ -0,0 +1,336 @@
+package chain
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/redis"
+	metricsMemory "cache-chain/pkg/metrics/memory"
+)
+
+// Both lease implementations must satisfy the interface
+var (
+	_ Lease       = (*LocalLease)(nil)
+	_ FencedLease = (*redis.Lease)(nil)
+)
+
+// sharedLayer lets several chains use the same layer, like replicas sharing
+// Redis. Closing a chain leaves it open.
+type sharedLayer struct {
+	cache.CacheLayer
+}
+
+func (sharedLayer) Close() error { return nil }
+
+// newLeasedChains creates n chains with private L1s over a shared L2.
+func newLeasedChains(t *testing.T, n int, config ChainConfig) []*Chain {
+	t.Helper()
+
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+	t.Cleanup(func() { l2.Close() })
+
+	chains := make([]*Chain, n)
+	for i := range chains {
+		l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+		c, err := NewWithConfig(config, l1, sharedLayer{l2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		chains[i] = c
+	}
+	return chains
+}
+
+func TestChain_Lease_SingleLoadAcrossInstances(t *testing.T) {
+	chains := newLeasedChains(t, 3, ChainConfig{Lease: NewLocalLease(), LeaseTTL: time.Second})
+
+	var loads int32
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(50 * time.Millisecond)
+		return "value", time.Minute, nil
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, c := range chains {
+		wg.Add(1)
+		go func(c *Chain) {
+			defer wg.Done()
+			value, err := c.GetOrLoad(ctx, "key", loader)
+			if err != nil {
+				t.Errorf("GetOrLoad failed: %v", err)
+			}
+			if value != "value" {
+				t.Errorf("Expected 'value', got %v", value)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Errorf("Expected 1 origin load across instances, got %d", n)
+	}
+}
+
+func TestChain_Lease_WaitTimeout(t *testing.T) {
+	lease := NewLocalLease()
+	chains := newLeasedChains(t, 1, ChainConfig{
+		Lease:     lease,
+		LeaseTTL:  time.Minute,
+		LeaseWait: 50 * time.Millisecond,
+	})
+
+	ctx := context.Background()
+
+	// Another instance holds the lease but never delivers
+	if _, acquired, _ := lease.Acquire(ctx, "key", time.Minute); !acquired {
+		t.Fatal("Expected lease to be acquired")
+	}
+
+	start := time.Now()
+	value, err := chains[0].GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "value", time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if value != "value" {
+		t.Errorf("Expected 'value', got %v", value)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait for the lease holder, waited %v", elapsed)
+	}
+}
+
+func TestChain_Lease_Fencing(t *testing.T) {
+	lease := NewLocalLease()
+	chains := newLeasedChains(t, 1, ChainConfig{Lease: lease, LeaseTTL: 20 * time.Millisecond})
+
+	ctx := context.Background()
+
+	// The load outlives the lease, and another instance takes it over
+	value, err := chains[0].GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		time.Sleep(40 * time.Millisecond)
+		if _, acquired, _ := lease.Acquire(ctx, "key", time.Minute); !acquired {
+			t.Error("Expected expired lease to be taken over")
+		}
+		return "late", time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	// The caller still gets its value, but the layers are left to the new holder
+	if value != "late" {
+		t.Errorf("Expected 'late', got %v", value)
+	}
+	if _, err := chains[0].Get(ctx, "key"); !cache.IsNotFound(err) {
+		t.Errorf("Expected fenced write to be skipped, got err=%v", err)
+	}
+}
+
+// fencedLease is a LocalLease whose tokens can fence writes to fencedLayer.
+// It counts Holds calls, which fenced writes make unnecessary.
+type fencedLease struct {
+	*LocalLease
+	holds int32
+}
+
+func (l *fencedLease) FenceKey(key string) string { return key }
+
+func (l *fencedLease) Holds(ctx context.Context, key string, token uint64) (bool, error) {
+	atomic.AddInt32(&l.holds, 1)
+	return l.LocalLease.Holds(ctx, key, token)
+}
+
+// fencedLayer is a shared layer storing fencedLease's leases.
+type fencedLayer struct {
+	sharedLayer
+	lease *LocalLease
+}
+
+func (f fencedLayer) SetEntryFenced(ctx context.Context, entry *cache.CacheEntry, fenceKey string, token uint64) (bool, error) {
+	f.lease.mu.Lock()
+	defer f.lease.mu.Unlock()
+
+	if held, ok := f.lease.leases[fenceKey]; !ok || held.token != token {
+		return false, nil
+	}
+	return true, f.CacheLayer.(cache.EntryLayer).SetEntry(ctx, entry)
+}
+
+func TestChain_Lease_FencedWrite(t *testing.T) {
+	lease := &fencedLease{LocalLease: NewLocalLease()}
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+	defer l2.Close()
+
+	c, err := NewWithConfig(ChainConfig{Lease: lease, LeaseTTL: time.Second},
+		memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"}),
+		fencedLayer{sharedLayer{l2}, lease.LocalLease},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "value", time.Minute, nil
+	}); err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	if value, err := l2.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("Expected fenced layer to hold 'value', got %v (err: %v)", value, err)
+	}
+	if n := atomic.LoadInt32(&lease.holds); n != 0 {
+		t.Errorf("Expected the fenced write to check the token, got %d Holds calls", n)
+	}
+
+	// A holder whose lease was taken over writes nothing
+	_, err = c.GetOrLoad(ctx, "late", func(ctx context.Context) (interface{}, time.Duration, error) {
+		// The lease expires and another instance takes it
+		lease.mu.Lock()
+		delete(lease.leases, "late")
+		lease.mu.Unlock()
+		lease.Acquire(ctx, "late", time.Minute)
+		return "late", time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if _, err := c.Get(ctx, "late"); !cache.IsNotFound(err) {
+		t.Errorf("Expected fenced write to be refused, got err=%v", err)
+	}
+}
+
+func TestChain_Lease_TakesOverReleasedLease(t *testing.T) {
+	mc := metricsMemory.NewMemoryCollector()
+	lease := NewLocalLease()
+	chains := newLeasedChains(t, 1, ChainConfig{
+		Lease:     lease,
+		LeaseTTL:  time.Minute,
+		LeaseWait: time.Minute,
+		Metrics:   mc,
+	})
+
+	ctx := context.Background()
+
+	// Another instance holds the lease, then its load fails and it lets go
+	token, acquired, _ := lease.Acquire(ctx, "key", time.Minute)
+	if !acquired {
+		t.Fatal("Expected lease to be acquired")
+	}
+	time.AfterFunc(100*time.Millisecond, func() {
+		lease.Release(ctx, "key", token)
+	})
+
+	start := time.Now()
+	value, err := chains[0].GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "value", time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if value != "value" {
+		t.Errorf("Expected 'value', got %v", value)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the released lease to be noticed early, waited %v", elapsed)
+	}
+
+	// Polls leave no trace: only the initial lookup is a miss
+	snapshot := mc.Snapshot()
+	if snapshot.ChainMisses != 1 {
+		t.Errorf("Expected 1 chain miss, got %d", snapshot.ChainMisses)
+	}
+	if misses := snapshot.LayerMetrics["L2"].Misses; misses != 1 {
+		t.Errorf("Expected 1 L2 miss, got %d", misses)
+	}
+
+	// The lease was taken over and released after the load
+	if _, acquired, _ := lease.Acquire(ctx, "key", time.Minute); !acquired {
+		t.Error("Expected lease to be released after the load")
+	}
+}
+
+func TestChain_Lease_ServesGraceWhileHeld(t *testing.T) {
+	lease := NewLocalLease()
+	chains := newLeasedChains(t, 1, ChainConfig{
+		Lease:        lease,
+		StaleIfError: time.Minute,
+	})
+
+	ctx := context.Background()
+	c := chains[0]
+
+	if err := c.Set(ctx, "key", "old", 10*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	if _, acquired, _ := lease.Acquire(ctx, "key", time.Minute); !acquired {
+		t.Fatal("Expected lease to be acquired")
+	}
+
+	var loads int32
+	value, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&loads, 1)
+		return "new", time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if value != "old" {
+		t.Errorf("Expected grace value while the lease is held, got %v", value)
+	}
+	if n := atomic.LoadInt32(&loads); n != 0 {
+		t.Errorf("Expected no origin load, got %d", n)
+	}
+}
+
+func TestLocalLease(t *testing.T) {
+	lease := NewLocalLease()
+	ctx := context.Background()
+
+	token, acquired, err := lease.Acquire(ctx, "key", 30*time.Millisecond)
+	if err != nil || !acquired {
+		t.Fatalf("Expected lease to be acquired, got %v (err: %v)", acquired, err)
+	}
+
+	if _, acquired, _ := lease.Acquire(ctx, "key", time.Minute); acquired {
+		t.Error("Expected held lease to be refused")
+	}
+	if _, acquired, _ := lease.Acquire(ctx, "other", time.Minute); !acquired {
+		t.Error("Expected lease on another key to be acquired")
+	}
+
+	// Releasing with another token is a no-op
+	lease.Release(ctx, "key", token+100)
+	if held, _ := lease.Holds(ctx, "key", token); !held {
+		t.Error("Expected lease to survive release with a wrong token")
+	}
+
+	// Expired leases can be taken over with a new token
+	time.Sleep(50 * time.Millisecond)
+	if held, _ := lease.Holds(ctx, "key", token); held {
+		t.Error("Expected lease to expire")
+	}
+	next, acquired, _ := lease.Acquire(ctx, "key", time.Minute)
+	if !acquired || next == token {
+		t.Errorf("Expected takeover with a new token, got %d (acquired: %v)", next, acquired)
+	}
+
+	// The old holder's release doesn't affect the new holder
+	lease.Release(ctx, "key", token)
+	if held, _ := lease.Holds(ctx, "key", next); !held {
+		t.Error("Expected new holder to keep the lease")
+	}
+}
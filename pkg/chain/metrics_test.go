@@ -233,6 +233,47 @@ func TestResilientLayer_CircuitBreakerMetrics(t *testing.T) {
 	}
 }
 
+// TestChain_GetOrLoad_Metrics tests that origin loads are reported to the collector.
+func TestChain_GetOrLoad_Metrics(t *testing.T) {
+	mc := metricsMemory.NewMemoryCollector()
+
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1", MaxSize: 100})
+
+	chain, err := NewWithConfig(ChainConfig{
+		Metrics: mc,
+	}, l1)
+	if err != nil {
+		t.Fatalf("NewWithConfig failed: %v", err)
+	}
+	defer chain.Close()
+
+	ctx := context.Background()
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "value1", time.Hour, nil
+	}
+
+	// First call misses and loads
+	if _, err := chain.GetOrLoad(ctx, "key1", loader); err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	// Second call is served from L1
+	if _, err := chain.GetOrLoad(ctx, "key1", loader); err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	snapshot := mc.Snapshot()
+	if snapshot.Loads != 1 {
+		t.Errorf("Expected 1 load, got %d", snapshot.Loads)
+	}
+	if snapshot.LoadErrors != 0 {
+		t.Errorf("Expected 0 load errors, got %d", snapshot.LoadErrors)
+	}
+	if snapshot.ChainHits != 1 {
+		t.Errorf("Expected 1 chain hit, got %d", snapshot.ChainHits)
+	}
+}
+
 // flakyLayer is a test layer that fails a specified number of times.
 type flakyLayer struct {
 	failCount int
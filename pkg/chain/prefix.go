@@ -0,0 +1,94 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+
+	"cache-chain/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// PrefixProgress reports the advance of DeletePrefixWithProgress.
+type PrefixProgress struct {
+	LayerIndex int    // Index of the layer being processed
+	LayerName  string // Name of the layer being processed
+	LayerKeys  int    // Keys removed from this layer so far
+	TotalKeys  int    // Keys removed from all layers so far
+}
+
+// DeletePrefix removes every key starting with prefix from all layers and
+// returns how many were removed, summed over layers. Use KeyPattern.Prefix to
+// invalidate all keys of a pattern. See DeletePrefixWithProgress.
+func (c *Chain) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	return c.DeletePrefixWithProgress(ctx, prefix, nil)
+}
+
+// DeletePrefixWithProgress is like DeletePrefix and calls progress (if not
+// nil) after each batch of keys removed, which lets callers follow the
+// invalidation of huge keyspaces. Layers implementing cache.PrefixLayer
+// (memory, Redis) find the keys themselves; for the others an error wrapping
+// cache.ErrNotSupported is returned once all other layers were processed.
+// Under a WritePolicy the origin is left alone.
+// If an InvalidationBus is configured, other instances are told to evict
+// the keys of each batch.
+func (c *Chain) DeletePrefixWithProgress(ctx context.Context, prefix string, progress func(PrefixProgress)) (int, error) {
+	if prefix == "" {
+		return 0, cache.ErrInvalidKey
+	}
+
+	c.grace.forgetPrefix(prefix)
+
+	var lastErr error
+	total := 0
+	reported := 0
+
+	for i, layer := range c.cacheLayers() {
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		default:
+		}
+
+		pl, ok := layer.(cache.PrefixLayer)
+		if !ok {
+			lastErr = fmt.Errorf("chain: layer %s can't delete by prefix: %w", layer.Name(), cache.ErrNotSupported)
+			continue
+		}
+
+		layerKeys := 0
+		deleted, err := pl.DeletePrefix(ctx, prefix, func(keys []string) {
+			layerKeys += len(keys)
+			reported += len(keys)
+			c.publishInvalidation(ctx, keys...)
+			if progress != nil {
+				progress(PrefixProgress{
+					LayerIndex: i,
+					LayerName:  layer.Name(),
+					LayerKeys:  layerKeys,
+					TotalKeys:  reported,
+				})
+			}
+		})
+		total += deleted
+		if err != nil {
+			c.logger.Warn("layer prefix deletion failed",
+				zap.Int("layer_index", i),
+				zap.String("layer_name", layer.Name()),
+				zap.String("prefix", prefix),
+				zap.Int("deleted", deleted),
+				zap.Error(err),
+			)
+			lastErr = err
+			// Continue with other layers even if one fails
+		}
+	}
+
+	c.logger.Debug("prefix deleted",
+		zap.String("prefix", prefix),
+		zap.Int("keys", total),
+	)
+
+	return total, lastErr
+}
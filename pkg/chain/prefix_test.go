@@ -0,0 +1,124 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
+)
+
+func TestChain_DeletePrefix(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1", PrefixIndex: true})
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	users := cache.NewKeyPattern("user", ":")
+	for i := 0; i < 1500; i++ {
+		c.Set(ctx, users.Build(fmt.Sprint(i)), i, time.Minute)
+	}
+	c.Set(ctx, "users", "other", time.Minute)
+
+	var updates []PrefixProgress
+	deleted, err := c.DeletePrefixWithProgress(ctx, users.Prefix(), func(p PrefixProgress) {
+		updates = append(updates, p)
+	})
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if deleted != 3000 {
+		t.Errorf("Expected 3000 keys deleted over 2 layers, got %d", deleted)
+	}
+
+	if len(updates) < 4 {
+		t.Fatalf("Expected progress for each batch, got %d updates", len(updates))
+	}
+	last := updates[len(updates)-1]
+	if last.LayerIndex != 1 || last.LayerName != "L2" || last.LayerKeys != 1500 || last.TotalKeys != 3000 {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+
+	for _, layer := range []*memory.MemoryCache{l1, l2} {
+		if _, err := layer.Get(ctx, "user:7"); err != cache.ErrKeyNotFound {
+			t.Errorf("Expected user:7 to be removed from %s, got %v", layer.Name(), err)
+		}
+		if val, _ := layer.Get(ctx, "users"); val != "other" {
+			t.Errorf("Expected users to survive in %s, got %v", layer.Name(), val)
+		}
+	}
+
+	if _, err := c.DeletePrefix(ctx, ""); err != cache.ErrInvalidKey {
+		t.Errorf("Expected ErrInvalidKey for empty prefix, got %v", err)
+	}
+}
+
+func TestChain_DeletePrefix_UnsupportedLayer(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := mock.NewMockLayerWithDefaults("L2")
+
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	l1.Set(ctx, "user:1", 1, time.Minute)
+
+	deleted, err := c.DeletePrefix(ctx, "user:")
+	if !errors.Is(err, cache.ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected supported layers to be processed, got %d deleted", deleted)
+	}
+}
+
+func TestChain_DeletePrefix_Broadcast(t *testing.T) {
+	bus := NewLocalInvalidationBus()
+
+	shared := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "shared"})
+	defer shared.Close()
+
+	newInstance := func() (*Chain, *memory.MemoryCache) {
+		local := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "local"})
+		c, err := NewWithConfig(ChainConfig{InvalidationBus: bus, LocalLayers: 1}, local, sharedPrefixLayer{shared})
+		if err != nil {
+			t.Fatalf("Failed to create chain: %v", err)
+		}
+		return c, local
+	}
+
+	a, _ := newInstance()
+	defer a.Close()
+	b, bLocal := newInstance()
+	defer b.Close()
+
+	ctx := context.Background()
+	b.Set(ctx, "user:1", "v", time.Minute)
+
+	if _, err := a.DeletePrefix(ctx, "user:"); err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+
+	if _, err := bLocal.Get(ctx, "user:1"); err != cache.ErrKeyNotFound {
+		t.Errorf("Expected user:1 to be evicted from the other instance, got %v", err)
+	}
+}
+
+// sharedPrefixLayer is a sharedLayer that keeps prefix deletion.
+type sharedPrefixLayer struct {
+	*memory.MemoryCache
+}
+
+func (sharedPrefixLayer) Close() error { return nil }
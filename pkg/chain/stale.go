@@ -0,0 +1,108 @@
+package chain
+
+import (
+	"context"
+	"time"
+
+	"cache-chain/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// KeyLoaderFunc loads the value for key from the origin (source of truth).
+// It returns the value along with the TTL it should be cached with.
+type KeyLoaderFunc func(ctx context.Context, key string) (interface{}, time.Duration, error)
+
+// RegisterLoader sets the loader used by Get to refresh stale entries in the
+// background. Without a loader, Get keeps serving stale values until their
+// hard TTL. GetOrLoad always refreshes with its own loader.
+func (c *Chain) RegisterLoader(loader KeyLoaderFunc) {
+	if loader == nil {
+		c.loader.Store(nil)
+		return
+	}
+	c.loader.Store(&loader)
+}
+
+// SetWithSoftTTL writes the value to all layers with a soft and a hard TTL.
+// After softTTL the value is stale: reads still return it but trigger a
+// background refresh. After hardTTL it is gone. The hard TTL is adjusted per
+// layer using the configured TTLStrategy, and the soft TTL never exceeds it.
+// Layers that can't store metadata only keep the value until the hard TTL.
+func (c *Chain) SetWithSoftTTL(ctx context.Context, key string, value interface{}, softTTL, hardTTL time.Duration) error {
+	return c.set(ctx, key, value, hardTTL, c.setEntryLayer(key, value, softTTL, hardTTL, 0))
+}
+
+// setEntryLayer returns the update writing the value with its metadata to a layer.
+// loadDuration is how long the origin took to produce it (0 if unknown).
+func (c *Chain) setEntryLayer(key string, value interface{}, softTTL, hardTTL, loadDuration time.Duration) layerWrite {
+	if softTTL > hardTTL {
+		softTTL = hardTTL
+	}
+
+	now := time.Now()
+	return func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		// Calculate TTLs for this layer using strategy
+		layerHard := c.layerTTL(i, key, hardTTL)
+		layerSoft := softTTL
+		if layerSoft > layerHard {
+			layerSoft = layerHard
+		}
+
+		return setEntry(ctx, layer, &cache.CacheEntry{
+			Key:          key,
+			Value:        value,
+			ExpiresAt:    now.Add(layerHard),
+			StaleAt:      now.Add(layerSoft),
+			CreatedAt:    now,
+			Version:      now.UnixNano(),
+			LoadDuration: loadDuration,
+		})
+	}
+}
+
+// refreshInBackground reloads a stale key unless a refresh is already running.
+// The refresh outlives the request that triggered it and is cancelled on Close.
+func (c *Chain) refreshInBackground(key string, loader KeyLoaderFunc) {
+	if _, running := c.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	c.logger.Debug("serving stale value - refreshing in background",
+		zap.String("key", key),
+	)
+
+	c.refreshWG.Add(1)
+	go func() {
+		defer c.refreshWG.Done()
+		defer c.refreshing.Delete(key)
+
+		// Errors are logged by load; the stale value stays until its hard TTL
+		_, _ = c.load(c.refreshCtx, key, func(ctx context.Context) (interface{}, time.Duration, error) {
+			return loader(ctx, key)
+		})
+	}()
+}
+
+// getEntry reads an entry from a layer, falling back to Get for layers
+// without metadata support.
+func getEntry(ctx context.Context, layer cache.CacheLayer, key string) (*cache.CacheEntry, error) {
+	if el, ok := layer.(cache.EntryLayer); ok {
+		return el.GetEntry(ctx, key)
+	}
+
+	value, err := layer.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &cache.CacheEntry{Key: key, Value: value}, nil
+}
+
+// setEntry writes an entry to a layer, falling back to Set with the remaining
+// TTL for layers without metadata support.
+func setEntry(ctx context.Context, layer cache.CacheLayer, entry *cache.CacheEntry) error {
+	if el, ok := layer.(cache.EntryLayer); ok {
+		return el.SetEntry(ctx, entry)
+	}
+	return layer.Set(ctx, entry.Key, entry.Value, entry.TimeToLive())
+}
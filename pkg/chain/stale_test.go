@@ -0,0 +1,202 @@
+package chain
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+)
+
+func TestChain_StaleWhileRevalidate_ServesStaleAndRefreshesOnce(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := NewWithConfig(ChainConfig{StaleWhileRevalidate: time.Minute}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var loads int32
+	release := make(chan struct{})
+	c.RegisterLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "fresh", time.Minute, nil
+	})
+
+	ctx := context.Background()
+	if err := c.Set(ctx, "key", "stale", 20*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Concurrent readers get the stale value without waiting for the loader
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.Get(ctx, "key")
+			if err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+			if value != "stale" {
+				t.Errorf("Expected stale value, got %v", value)
+			}
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+
+	// Wait for the refresh to land
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, _ := c.Get(ctx, "key")
+		if value == "fresh" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected refreshed value, got %v", value)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Errorf("Expected exactly 1 background refresh, got %d", n)
+	}
+}
+
+func TestChain_StaleWhileRevalidate_HardTTL(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := NewWithConfig(ChainConfig{StaleWhileRevalidate: 30 * time.Millisecond}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	c.Set(ctx, "key", "value", 20*time.Millisecond)
+
+	time.Sleep(80 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "key"); !cache.IsNotFound(err) {
+		t.Errorf("Expected miss after hard TTL, got %v", err)
+	}
+}
+
+func TestChain_StaleWhileRevalidate_NoLoaderServesStale(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := NewWithConfig(ChainConfig{StaleWhileRevalidate: time.Minute}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	c.Set(ctx, "key", "value", 10*time.Millisecond)
+
+	time.Sleep(30 * time.Millisecond)
+
+	value, err := c.Get(ctx, "key")
+	if err != nil || value != "value" {
+		t.Errorf("Expected stale value, got %v (err: %v)", value, err)
+	}
+}
+
+func TestChain_StaleWhileRevalidate_GetOrLoad(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := NewWithConfig(ChainConfig{StaleWhileRevalidate: time.Minute}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	var loads int32
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		n := atomic.AddInt32(&loads, 1)
+		return n, 20 * time.Millisecond, nil
+	}
+
+	value, err := c.GetOrLoad(ctx, "key", loader)
+	if err != nil || value != int32(1) {
+		t.Fatalf("Expected first load, got %v (err: %v)", value, err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	// Stale: the old value is returned while GetOrLoad's loader refreshes
+	value, err = c.GetOrLoad(ctx, "key", loader)
+	if err != nil || value != int32(1) {
+		t.Errorf("Expected stale value 1, got %v (err: %v)", value, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	value, _ = c.Get(ctx, "key")
+	if value != int32(2) {
+		t.Errorf("Expected refreshed value 2, got %v", value)
+	}
+}
+
+func TestChain_StaleWhileRevalidate_WarmUpKeepsMetadata(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	staleAt := time.Now().Add(time.Minute)
+	expiresAt := time.Now().Add(time.Hour)
+	l2.SetEntry(ctx, &cache.CacheEntry{Key: "key", Value: "value", StaleAt: staleAt, ExpiresAt: expiresAt})
+
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	c.Flush(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	entry, err := l1.GetEntry(ctx, "key")
+	if err != nil {
+		t.Fatalf("Expected L1 to be warmed, got %v", err)
+	}
+	if !entry.StaleAt.Equal(staleAt) || !entry.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected warmed entry to keep stale/expiry times, got %v/%v", entry.StaleAt, entry.ExpiresAt)
+	}
+}
+
+func TestChain_SetWithSoftTTL_LayerStrategy(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+	c, err := NewWithConfig(ChainConfig{
+		TTLStrategy: &CustomTTLStrategy{TTLs: []time.Duration{time.Second, time.Hour}},
+	}, l1, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.SetWithSoftTTL(ctx, "key", "value", time.Minute, time.Hour); err != nil {
+		t.Fatalf("SetWithSoftTTL failed: %v", err)
+	}
+
+	// L1's hard TTL is capped to 1s, so its soft TTL is too
+	e1, _ := l1.GetEntry(ctx, "key")
+	if e1.StaleAt.After(e1.ExpiresAt) || time.Until(e1.ExpiresAt) > time.Second {
+		t.Errorf("Expected L1 soft TTL capped at its 1s hard TTL, got stale=%v expires=%v", e1.StaleAt, e1.ExpiresAt)
+	}
+
+	e2, _ := l2.GetEntry(ctx, "key")
+	if d := time.Until(e2.StaleAt); d < 59*time.Second || d > time.Minute {
+		t.Errorf("Expected L2 soft TTL ~1m, got %v", d)
+	}
+}
@@ -0,0 +1,75 @@
+package chain
+
+import (
+	"context"
+	"time"
+
+	"cache-chain/pkg/cache"
+
+	"go.uber.org/zap"
+)
+
+// SetWithTags writes the value to all layers like Set and attaches tags to it,
+// so the group can later be removed with InvalidateTag without knowing its
+// keys. Layers implementing cache.TaggedLayer index the tags; others store the
+// plain value. Tagged values are written without soft TTL metadata, even with
+// StaleWhileRevalidate enabled. With a WritePolicy, the origin is written as
+// the policy dictates.
+// If an InvalidationBus is configured, other instances are told to evict the key.
+func (c *Chain) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	return c.set(ctx, key, value, ttl, func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		layerTTL := c.layerTTL(i, key, ttl)
+		if tl, ok := layer.(cache.TaggedLayer); ok {
+			return tl.SetWithTags(ctx, key, value, layerTTL, tags)
+		}
+		return layer.Set(ctx, key, value, layerTTL)
+	})
+}
+
+// InvalidateTag removes every key tagged with tag from all layers.
+// Each layer's tag index is consulted, and the keys found in any of them are
+// deleted from every layer, since upper layers may hold values warmed up
+// from below without their tags. Under a WritePolicy the origin is left alone.
+// If an InvalidationBus is configured, other instances are told to evict the keys.
+func (c *Chain) InvalidateTag(ctx context.Context, tag string) error {
+	var keys []string
+	var lastErr error
+
+	for i, layer := range c.cacheLayers() {
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		tl, ok := layer.(cache.TaggedLayer)
+		if !ok {
+			continue
+		}
+
+		invalidated, err := tl.InvalidateTag(ctx, tag)
+		if err != nil {
+			c.logger.Warn("layer tag invalidation failed",
+				zap.Int("layer_index", i),
+				zap.String("layer_name", layer.Name()),
+				zap.String("tag", tag),
+				zap.Error(err),
+			)
+			lastErr = err
+			// Continue with other layers even if one fails
+		}
+		keys = append(keys, invalidated...)
+	}
+
+	c.logger.Debug("tag invalidated",
+		zap.String("tag", tag),
+		zap.Int("keys", len(keys)),
+	)
+
+	if err := c.evict(ctx, keys); err != nil {
+		lastErr = err
+	}
+
+	return lastErr
+}
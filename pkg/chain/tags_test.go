@@ -0,0 +1,94 @@
+package chain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+)
+
+func TestChain_InvalidateTag(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	l2 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L2"})
+
+	c, err := New(l1, l2)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	c.SetWithTags(ctx, "order:1", "a", time.Minute, "account:42")
+	c.SetWithTags(ctx, "order:2", "b", time.Minute, "account:42", "region:eu")
+	c.SetWithTags(ctx, "order:3", "c", time.Minute, "account:7")
+
+	// order:1 reaches L1 again through warm-up, without its tags
+	l1.Delete(ctx, "order:1")
+	if _, err := c.Get(ctx, "order:1"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	// Wait for async warm-up
+	time.Sleep(50 * time.Millisecond)
+	if _, err := l1.Get(ctx, "order:1"); err != nil {
+		t.Fatalf("Expected order:1 to be warmed into L1, got %v", err)
+	}
+
+	if err := c.InvalidateTag(ctx, "account:42"); err != nil {
+		t.Fatalf("InvalidateTag failed: %v", err)
+	}
+
+	for _, layer := range []*memory.MemoryCache{l1, l2} {
+		for _, key := range []string{"order:1", "order:2"} {
+			if _, err := layer.Get(ctx, key); err != cache.ErrKeyNotFound {
+				t.Errorf("Expected %s to be removed from %s, got %v", key, layer.Name(), err)
+			}
+		}
+		if val, _ := layer.Get(ctx, "order:3"); val != "c" {
+			t.Errorf("Expected order:3 to survive in %s, got %v", layer.Name(), val)
+		}
+	}
+}
+
+func TestChain_InvalidateTag_Broadcast(t *testing.T) {
+	bus := NewLocalInvalidationBus()
+
+	shared := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "shared"})
+	defer shared.Close()
+
+	newInstance := func() (*Chain, *memory.MemoryCache) {
+		local := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "local"})
+		c, err := NewWithConfig(ChainConfig{InvalidationBus: bus, LocalLayers: 1}, local, sharedLayer{shared})
+		if err != nil {
+			t.Fatalf("Failed to create chain: %v", err)
+		}
+		return c, local
+	}
+
+	a, _ := newInstance()
+	defer a.Close()
+	b, bLocal := newInstance()
+	defer b.Close()
+
+	ctx := context.Background()
+	a.SetWithTags(ctx, "order:1", "a", time.Minute, "account:42")
+
+	// b caches the value in its local layer without the tag
+	if _, err := b.Get(ctx, "order:1"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	// Wait for async warm-up
+	time.Sleep(50 * time.Millisecond)
+	if _, err := bLocal.Get(ctx, "order:1"); err != nil {
+		t.Fatalf("Expected order:1 to be warmed into the local layer, got %v", err)
+	}
+
+	if err := a.InvalidateTag(ctx, "account:42"); err != nil {
+		t.Fatalf("InvalidateTag failed: %v", err)
+	}
+
+	if _, err := bLocal.Get(ctx, "order:1"); err != cache.ErrKeyNotFound {
+		t.Errorf("Expected order:1 to be evicted from the other instance, got %v", err)
+	}
+}
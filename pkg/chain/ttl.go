@@ -2,6 +2,8 @@ package chain
 
 import (
 	"math"
+	"math/rand/v2"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,31 @@ type TTLStrategy interface {
 	GetTTL(layerIndex int, baseTTL time.Duration) time.Duration
 }
 
+// TTLContext describes the layer and key a TTL is computed for.
+type TTLContext struct {
+	LayerIndex int    // Index of the layer, 0 for L1
+	LayerCount int    // Number of layers in the chain
+	LayerName  string // Name of the layer
+	Key        string // Key being written
+}
+
+// ContextualTTLStrategy is a TTLStrategy that also sees the chain context.
+// The chain calls TTLFor instead of GetTTL on strategies implementing it.
+type ContextualTTLStrategy interface {
+	TTLStrategy
+
+	// TTLFor returns the TTL for the layer and key described by tc
+	TTLFor(tc TTLContext, baseTTL time.Duration) time.Duration
+}
+
+// ttlFor computes the TTL with the richest API the strategy supports.
+func ttlFor(s TTLStrategy, tc TTLContext, baseTTL time.Duration) time.Duration {
+	if cs, ok := s.(ContextualTTLStrategy); ok {
+		return cs.TTLFor(tc, baseTTL)
+	}
+	return s.GetTTL(tc.LayerIndex, baseTTL)
+}
+
 // UniformTTLStrategy uses the same TTL for all layers.
 type UniformTTLStrategy struct{}
 
@@ -25,10 +52,16 @@ type DecayingTTLStrategy struct {
 	DecayFactor float64 // e.g., 0.5 means each layer has half the TTL of the next
 }
 
-// GetTTL returns decaying TTL based on layer index.
-// Layer 0 (L1) has shortest TTL, last layer has full baseTTL.
+// GetTTL returns baseTTL: without the layer count it can't tell how far a
+// layer is from the last one. The chain calls TTLFor, which decays.
 func (s *DecayingTTLStrategy) GetTTL(layerIndex int, baseTTL time.Duration) time.Duration {
-	if s.DecayFactor <= 0 || s.DecayFactor >= 1 {
+	return baseTTL
+}
+
+// TTLFor returns decaying TTL based on the layer's distance from the last layer.
+// Layer 0 (L1) has shortest TTL, last layer has full baseTTL.
+func (s *DecayingTTLStrategy) TTLFor(tc TTLContext, baseTTL time.Duration) time.Duration {
+	if s.DecayFactor <= 0 || s.DecayFactor >= 1 || tc.LayerCount <= 0 {
 		return baseTTL
 	}
 
@@ -37,8 +70,10 @@ func (s *DecayingTTLStrategy) GetTTL(layerIndex int, baseTTL time.Duration) time
 	// L0: baseTTL * 0.25 (0.5^2)
 	// L1: baseTTL * 0.5  (0.5^1)
 	// L2: baseTTL * 1.0  (0.5^0)
-	numLayers := layerIndex + 1
-	exponent := float64(numLayers - layerIndex - 1)
+	exponent := float64(tc.LayerCount - tc.LayerIndex - 1)
+	if exponent < 0 {
+		exponent = 0
+	}
 	factor := math.Pow(s.DecayFactor, exponent)
 
 	return time.Duration(float64(baseTTL) * factor)
@@ -56,3 +91,96 @@ func (s *CustomTTLStrategy) GetTTL(layerIndex int, baseTTL time.Duration) time.D
 	}
 	return baseTTL
 }
+
+// PrefixTTLRule overrides the TTL of keys starting with Prefix.
+type PrefixTTLRule struct {
+	Prefix string
+
+	// TTL replaces the base TTL passed by the caller (optional, kept if 0)
+	TTL time.Duration
+
+	// Strategy distributes the TTL across layers (optional, defaults to
+	// the PrefixTTLStrategy's Default)
+	Strategy TTLStrategy
+}
+
+// PrefixTTLStrategy applies per-key-prefix rules, e.g. short TTLs for
+// "session:" keys and long ones for "config:". The longest matching prefix
+// wins; keys without a match use Default.
+type PrefixTTLStrategy struct {
+	Rules   []PrefixTTLRule
+	Default TTLStrategy // optional, defaults to UniformTTLStrategy
+}
+
+// GetTTL applies Default, since the key is unknown.
+func (s *PrefixTTLStrategy) GetTTL(layerIndex int, baseTTL time.Duration) time.Duration {
+	return s.defaultStrategy().GetTTL(layerIndex, baseTTL)
+}
+
+// TTLFor applies the rule matching the key.
+func (s *PrefixTTLStrategy) TTLFor(tc TTLContext, baseTTL time.Duration) time.Duration {
+	strategy := s.defaultStrategy()
+
+	var match *PrefixTTLRule
+	for i := range s.Rules {
+		rule := &s.Rules[i]
+		if strings.HasPrefix(tc.Key, rule.Prefix) && (match == nil || len(rule.Prefix) > len(match.Prefix)) {
+			match = rule
+		}
+	}
+	if match != nil {
+		if match.TTL > 0 {
+			baseTTL = match.TTL
+		}
+		if match.Strategy != nil {
+			strategy = match.Strategy
+		}
+	}
+
+	return ttlFor(strategy, tc, baseTTL)
+}
+
+func (s *PrefixTTLStrategy) defaultStrategy() TTLStrategy {
+	if s.Default == nil {
+		return &UniformTTLStrategy{}
+	}
+	return s.Default
+}
+
+// JitterTTLStrategy randomizes the TTLs of another strategy by up to ±Percent
+// percent, so keys written together don't all expire at the same instant.
+type JitterTTLStrategy struct {
+	Strategy TTLStrategy // optional, defaults to UniformTTLStrategy
+	Percent  float64     // e.g., 10 means ±10%
+}
+
+// GetTTL returns the jittered TTL of the wrapped strategy.
+func (s *JitterTTLStrategy) GetTTL(layerIndex int, baseTTL time.Duration) time.Duration {
+	return s.jitter(s.strategy().GetTTL(layerIndex, baseTTL))
+}
+
+// TTLFor returns the jittered TTL of the wrapped strategy, passing the context on.
+func (s *JitterTTLStrategy) TTLFor(tc TTLContext, baseTTL time.Duration) time.Duration {
+	return s.jitter(ttlFor(s.strategy(), tc, baseTTL))
+}
+
+func (s *JitterTTLStrategy) strategy() TTLStrategy {
+	if s.Strategy == nil {
+		return &UniformTTLStrategy{}
+	}
+	return s.Strategy
+}
+
+func (s *JitterTTLStrategy) jitter(ttl time.Duration) time.Duration {
+	if s.Percent <= 0 || ttl <= 0 {
+		return ttl
+	}
+
+	// Uniform in [-Percent%, +Percent%]
+	delta := (rand.Float64()*2 - 1) * s.Percent / 100
+	jittered := time.Duration(float64(ttl) * (1 + delta))
+	if jittered <= 0 {
+		return ttl
+	}
+	return jittered
+}
@@ -216,3 +216,83 @@ func TestTTLStrategy_RealWorld(t *testing.T) {
 		}
 	})
 }
+
+func TestDecayingTTLStrategy_TTLFor(t *testing.T) {
+	strategy := &DecayingTTLStrategy{DecayFactor: 0.5}
+	baseTTL := 8 * time.Hour
+
+	expected := []time.Duration{2 * time.Hour, 4 * time.Hour, 8 * time.Hour}
+	for i, want := range expected {
+		ttl := strategy.TTLFor(TTLContext{LayerIndex: i, LayerCount: 3}, baseTTL)
+		if ttl != want {
+			t.Errorf("Layer %d: expected %v, got %v", i, want, ttl)
+		}
+	}
+
+	// Unknown layer count: no decay
+	if ttl := strategy.TTLFor(TTLContext{LayerIndex: 0}, baseTTL); ttl != baseTTL {
+		t.Errorf("Expected %v without layer count, got %v", baseTTL, ttl)
+	}
+}
+
+func TestPrefixTTLStrategy(t *testing.T) {
+	strategy := &PrefixTTLStrategy{
+		Rules: []PrefixTTLRule{
+			{Prefix: "session:", TTL: 5 * time.Minute},
+			{Prefix: "session:admin:", TTL: time.Minute},
+			{Prefix: "config:", Strategy: &CustomTTLStrategy{TTLs: []time.Duration{time.Minute}}},
+		},
+		Default: &DecayingTTLStrategy{DecayFactor: 0.5},
+	}
+	baseTTL := time.Hour
+
+	tests := []struct {
+		key      string
+		layer    int
+		expected time.Duration
+	}{
+		{"session:123", 0, 150 * time.Second}, // rule TTL, decayed by default strategy
+		{"session:123", 1, 5 * time.Minute},
+		{"session:admin:1", 1, time.Minute}, // longest prefix wins
+		{"config:flags", 0, time.Minute},
+		{"config:flags", 1, time.Hour},
+		{"user:1", 0, 30 * time.Minute}, // default strategy decays
+		{"user:1", 1, time.Hour},
+	}
+
+	for _, tt := range tests {
+		ttl := strategy.TTLFor(TTLContext{LayerIndex: tt.layer, LayerCount: 2, Key: tt.key}, baseTTL)
+		if ttl != tt.expected {
+			t.Errorf("%s at layer %d: expected %v, got %v", tt.key, tt.layer, tt.expected, ttl)
+		}
+	}
+}
+
+func TestJitterTTLStrategy(t *testing.T) {
+	strategy := &JitterTTLStrategy{Percent: 10}
+	baseTTL := time.Hour
+
+	min, max := baseTTL, baseTTL
+	for i := 0; i < 1000; i++ {
+		ttl := strategy.GetTTL(0, baseTTL)
+		if ttl < 54*time.Minute || ttl > 66*time.Minute {
+			t.Fatalf("Expected TTL within ±10%% of %v, got %v", baseTTL, ttl)
+		}
+		if ttl < min {
+			min = ttl
+		}
+		if ttl > max {
+			max = ttl
+		}
+	}
+	if max-min < time.Minute {
+		t.Errorf("Expected TTLs to be spread, got range [%v, %v]", min, max)
+	}
+
+	// The wrapped strategy still sees the chain context
+	decaying := &JitterTTLStrategy{Strategy: &DecayingTTLStrategy{DecayFactor: 0.5}, Percent: 10}
+	ttl := decaying.TTLFor(TTLContext{LayerIndex: 0, LayerCount: 2}, baseTTL)
+	if ttl < 27*time.Minute || ttl > 33*time.Minute {
+		t.Errorf("Expected jittered decayed TTL around 30m, got %v", ttl)
+	}
+}
@@ -0,0 +1,130 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+// Typed is a type-safe facade over a Chain.
+// In-process layers hand back the stored value as-is, while serialized layers
+// (e.g. Redis) hand back generic decoded shapes such as map[string]interface{}.
+// Typed converts the latter into T through the chain's codec (see
+// ChainConfig.Codec) so call sites never need type assertions.
+type Typed[T any] struct {
+	chain *Chain
+}
+
+// NewTyped creates a typed facade over the given chain.
+// Several facades with different types may share the same chain.
+func NewTyped[T any](c *Chain) *Typed[T] {
+	return &Typed[T]{chain: c}
+}
+
+// Get retrieves a value from the chain as T.
+// Returns cache.ErrTypeMismatch if the cached value cannot be converted to T.
+func (t *Typed[T]) Get(ctx context.Context, key string) (T, error) {
+	value, err := t.chain.Get(ctx, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return convertValue[T](t.chain.codec, value)
+}
+
+// GetWithMeta is like Get but also reports how the value was served.
+// See Chain.GetWithMeta.
+func (t *Typed[T]) GetWithMeta(ctx context.Context, key string) (T, GetMeta, error) {
+	value, meta, err := t.chain.GetWithMeta(ctx, key)
+	if err != nil {
+		var zero T
+		return zero, meta, err
+	}
+	typed, err := convertValue[T](t.chain.codec, value)
+	return typed, meta, err
+}
+
+// Set stores a value in all layers of the chain.
+func (t *Typed[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	return t.chain.Set(ctx, key, value, ttl)
+}
+
+// Delete removes the key from all layers of the chain.
+func (t *Typed[T]) Delete(ctx context.Context, key string) error {
+	return t.chain.Delete(ctx, key)
+}
+
+// GetOrLoad retrieves a value as T, loading it from the origin on a full-chain miss.
+// See Chain.GetOrLoad for the loading semantics.
+func (t *Typed[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, time.Duration, error)) (T, error) {
+	var zero T
+	if loader == nil {
+		return zero, errors.New("chain: loader is required")
+	}
+
+	value, err := t.chain.GetOrLoad(ctx, key, func(ctx context.Context) (interface{}, time.Duration, error) {
+		return loader(ctx)
+	})
+	if err != nil {
+		return zero, err
+	}
+	return convertValue[T](t.chain.codec, value)
+}
+
+// Chain returns the underlying untyped chain.
+func (t *Typed[T]) Chain() *Chain {
+	return t.chain
+}
+
+// convertValue converts a cached value to T.
+// Values that already have type T are passed through. The generic shapes
+// serialized layers decode into interface{} are encoded with codec and
+// decoded into T. Anything else, such as a value of another type held by an
+// in-process layer, or nil, is a mismatch.
+func convertValue[T any](codec cache.Codec, value interface{}) (T, error) {
+	var zero T
+
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+
+	if value == nil {
+		return zero, fmt.Errorf("%w: want %s, got nil", cache.ErrTypeMismatch, typeName[T]())
+	}
+	if !decodedShape(value) {
+		return zero, fmt.Errorf("%w: want %s, got %T", cache.ErrTypeMismatch, typeName[T](), value)
+	}
+
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return zero, fmt.Errorf("%w: failed to re-encode %T: %v", cache.ErrTypeMismatch, value, err)
+	}
+
+	var out T
+	if err := codec.Unmarshal(data, &out); err != nil {
+		return zero, fmt.Errorf("%w: cannot decode %T into %s: %v", cache.ErrTypeMismatch, value, typeName[T](), err)
+	}
+
+	return out, nil
+}
+
+// decodedShape reports whether value has one of the generic shapes codecs
+// decode into interface{} (maps, slices and basic values).
+func decodedShape(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}, []byte,
+		string, bool, float32, float64,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+// typeName returns a readable name for T, including interface types.
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
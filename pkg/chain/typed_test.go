@@ -0,0 +1,205 @@
+package chain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
+)
+
+type typedAccount struct {
+	ID      string  `json:"id"`
+	Balance float64 `json:"balance"`
+}
+
+func TestTyped_InProcessPassThrough(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	accounts := NewTyped[*typedAccount](c)
+	ctx := context.Background()
+
+	want := &typedAccount{ID: "42", Balance: 10.5}
+	if err := accounts.Set(ctx, "account:42", want, time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	got, err := accounts.Get(ctx, "account:42")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("Expected the same pointer to be returned, got %+v", got)
+	}
+}
+
+func TestTyped_DecodesSerializedShape(t *testing.T) {
+	// Simulates a serialized layer returning json.Unmarshal-into-interface{} output
+	l1 := mock.NewMockLayer("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		return map[string]interface{}{"id": "42", "balance": 10.5}, nil
+	}
+
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	got, err := NewTyped[typedAccount](c).Get(context.Background(), "account:42")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.ID != "42" || got.Balance != 10.5 {
+		t.Errorf("Unexpected decoded value: %+v", got)
+	}
+}
+
+func TestTyped_DecodesWithChainCodec(t *testing.T) {
+	// MessagePack decodes small integers as int8 and arrays as []interface{}
+	l1 := mock.NewMockLayer("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		return map[string]interface{}{"id": "42", "balance": int8(10), "tags": []interface{}{int64(1), int8(2)}}, nil
+	}
+
+	c, err := NewWithConfig(ChainConfig{Codec: cache.MsgpackCodec{}}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	type taggedAccount struct {
+		ID      string  `msgpack:"id"`
+		Balance float64 `msgpack:"balance"`
+		Tags    []int   `msgpack:"tags"`
+	}
+	got, err := NewTyped[taggedAccount](c).Get(context.Background(), "account:42")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.ID != "42" || got.Balance != 10 || len(got.Tags) != 2 || got.Tags[1] != 2 {
+		t.Errorf("Unexpected decoded value: %+v", got)
+	}
+}
+
+func TestTyped_NilValue(t *testing.T) {
+	l1 := mock.NewMockLayer("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		return nil, nil
+	}
+
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = NewTyped[typedAccount](c).Get(context.Background(), "account:42")
+	if !cache.IsTypeMismatch(err) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestTyped_TypeMismatch(t *testing.T) {
+	l1 := mock.NewMockLayer("L1")
+	l1.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		return 12345, nil
+	}
+
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = NewTyped[typedAccount](c).Get(context.Background(), "account:42")
+	if !cache.IsTypeMismatch(err) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestTyped_StructMismatch(t *testing.T) {
+	type profile struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Set(ctx, "account:42", profile{ID: "42", Name: "ann"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// L1 holds another struct: no partial decode into T
+	_, err = NewTyped[typedAccount](c).Get(ctx, "account:42")
+	if !cache.IsTypeMismatch(err) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestTyped_MissPropagates(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = NewTyped[string](c).Get(context.Background(), "missing")
+	if !cache.IsNotFound(err) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestTyped_GetOrLoad(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := New(l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	accounts := NewTyped[typedAccount](c)
+	got, err := accounts.GetOrLoad(context.Background(), "account:7", func(ctx context.Context) (typedAccount, time.Duration, error) {
+		return typedAccount{ID: "7", Balance: 3}, time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if got.ID != "7" {
+		t.Errorf("Expected ID 7, got %+v", got)
+	}
+}
+
+func TestTyped_GetWithMeta(t *testing.T) {
+	c := newDegradedChain(t, ChainConfig{StaleIfError: time.Minute}, cache.ErrLayerUnavailable)
+	accounts := NewTyped[typedAccount](c)
+	ctx := context.Background()
+
+	accounts.Set(ctx, "acct:1", typedAccount{ID: "1", Balance: 5}, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	got, meta, err := accounts.GetWithMeta(ctx, "acct:1")
+	if err != nil {
+		t.Fatalf("GetWithMeta failed: %v", err)
+	}
+	if got.ID != "1" || !meta.Stale {
+		t.Errorf("Expected stale account 1, got %+v (%+v)", got, meta)
+	}
+}
@@ -0,0 +1,224 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/logging"
+	"cache-chain/pkg/writer"
+
+	"go.uber.org/zap"
+)
+
+// WritePolicy selects how writes reach the last layer of the chain.
+// With any policy other than WriteAllLayers, the last layer is the origin
+// (the source of truth, e.g. a database adapter) and the layers above it are
+// caches: loads and warm-ups only fill the caches, and InvalidateTag and
+// DeletePrefix only evict from them.
+type WritePolicy int
+
+const (
+	// WriteAllLayers writes every layer in order, the last one included,
+	// treating them all as caches (default)
+	WriteAllLayers WritePolicy = iota
+
+	// WriteThrough writes the origin first, then the caches. If the origin
+	// fails, the caches are left untouched and the error is returned.
+	WriteThrough
+
+	// WriteBehind writes the caches immediately and queues the origin write
+	// on an AsyncWriter that retries failures and coalesces repeated writes
+	// of a key. Only a full queue fails the write.
+	WriteBehind
+
+	// WriteAround writes the origin, then evicts the key from the caches so
+	// the next read loads the new value. If the origin fails, the caches are
+	// left untouched and the error is returned.
+	WriteAround
+)
+
+// String returns the policy name.
+func (p WritePolicy) String() string {
+	switch p {
+	case WriteAllLayers:
+		return "all-layers"
+	case WriteThrough:
+		return "write-through"
+	case WriteBehind:
+		return "write-behind"
+	case WriteAround:
+		return "write-around"
+	default:
+		return fmt.Sprintf("WritePolicy(%d)", int(p))
+	}
+}
+
+// ErrOriginWrite is returned (wrapped with the cause) when the origin rejects
+// a write under WriteThrough, WriteAround or WriteBehind. No cache was
+// changed.
+var ErrOriginWrite = errors.New("chain: origin write failed")
+
+// layerWrite applies a change to layer, the chain's layer at index i
+// (which selects the layer TTL).
+type layerWrite func(ctx context.Context, i int, layer cache.CacheLayer) error
+
+// newWriteBehind creates the queue applying write-behind writes to origin.
+// With a journal configured, writes left by an earlier run are applied first.
+// Writes the origin keeps rejecting are logged before being handed to the
+// configured OnDeadLetter.
+func newWriteBehind(origin cache.CacheLayer, config ChainConfig, logger *logging.Logger) (*writer.AsyncWriter, error) {
+	wc := config.WriteBehind
+	if wc.MaxAttempts <= 0 {
+		wc.MaxAttempts = 5
+	}
+
+	onDeadLetter := wc.OnDeadLetter
+	wc.OnDeadLetter = func(dl writer.DeadLetter) {
+		logger.Error("write-behind origin write abandoned",
+			zap.String("layer_name", origin.Name()),
+			zap.String("key", dl.Key),
+			zap.Int("attempts", dl.Attempts),
+			zap.Error(dl.Err),
+		)
+		if onDeadLetter != nil {
+			onDeadLetter(dl)
+		}
+	}
+
+	return writer.OpenAsyncWriter(origin, wc, config.Metrics)
+}
+
+// write applies a change to the layers according to the write policy.
+// update changes layer i; under WriteAround the caches are evicted of keys
+// instead. If the origin rejects the change, an error wrapping ErrOriginWrite
+// is returned and the caches are left untouched.
+func (c *Chain) write(ctx context.Context, keys []string, update layerWrite) error {
+	caches := c.cacheCount()
+	if caches < len(c.layers) {
+		origin := c.layers[caches]
+		if c.writePolicy == WriteBehind {
+			origin = &behindLayer{w: c.behind, name: origin.Name()}
+		}
+		if err := update(ctx, caches, origin); err != nil {
+			c.logger.Warn("origin write failed - caches left untouched",
+				zap.String("policy", c.writePolicy.String()),
+				zap.String("layer_name", origin.Name()),
+				zap.Int("keys", len(keys)),
+				zap.Error(err),
+			)
+			return fmt.Errorf("%w: %w", ErrOriginWrite, err)
+		}
+	}
+
+	var lastErr error
+	for i := 0; i < caches; i++ {
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		var err error
+		if c.writePolicy == WriteAround {
+			err = evictLayer(ctx, c.layers[i], keys)
+		} else {
+			err = update(ctx, i, c.layers[i])
+		}
+		if err != nil {
+			lastErr = err
+			// Continue with other layers even if one fails
+		}
+	}
+	return lastErr
+}
+
+// writeCaches applies a change to the caches only, e.g. to store a value
+// loaded from the origin or to evict invalidated keys.
+func (c *Chain) writeCaches(ctx context.Context, update layerWrite) error {
+	var lastErr error
+	for i, layer := range c.cacheLayers() {
+		// Check for context cancellation
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err := update(ctx, i, layer); err != nil {
+			lastErr = err
+			// Continue with other layers even if one fails
+		}
+	}
+	return lastErr
+}
+
+// cacheCount returns the number of leading layers that are caches: all of
+// them, unless a write policy makes the last layer the origin.
+func (c *Chain) cacheCount() int {
+	if c.writePolicy == WriteAllLayers {
+		return len(c.layers)
+	}
+	return len(c.layers) - 1
+}
+
+// cacheLayers returns the layers that are caches.
+func (c *Chain) cacheLayers() []cache.CacheLayer {
+	return c.layers[:c.cacheCount()]
+}
+
+// evictLayer removes keys from layer.
+func evictLayer(ctx context.Context, layer cache.CacheLayer, keys []string) error {
+	if len(keys) == 1 {
+		return layer.Delete(ctx, keys[0])
+	}
+	return batchLayer(layer).DeleteMulti(ctx, keys)
+}
+
+// setLayer returns the update writing value to a layer, with soft TTL
+// metadata when StaleWhileRevalidate is enabled.
+func (c *Chain) setLayer(key string, value interface{}, ttl time.Duration) layerWrite {
+	if c.staleWhileRevalidate > 0 {
+		return c.setEntryLayer(key, value, ttl, ttl+c.staleWhileRevalidate, 0)
+	}
+	return func(ctx context.Context, i int, layer cache.CacheLayer) error {
+		return layer.Set(ctx, key, value, c.layerTTL(i, key, ttl))
+	}
+}
+
+// behindLayer queues the writes of the origin on the write-behind writer.
+type behindLayer struct {
+	w    *writer.AsyncWriter
+	name string
+}
+
+func (b *behindLayer) Get(ctx context.Context, key string) (interface{}, error) {
+	return nil, cache.ErrNotSupported
+}
+
+func (b *behindLayer) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return b.w.Write(ctx, key, value, ttl)
+}
+
+func (b *behindLayer) SetEntry(ctx context.Context, entry *cache.CacheEntry) error {
+	return b.w.WriteEntry(ctx, entry)
+}
+
+func (b *behindLayer) GetEntry(ctx context.Context, key string) (*cache.CacheEntry, error) {
+	return nil, cache.ErrNotSupported
+}
+
+func (b *behindLayer) Delete(ctx context.Context, key string) error {
+	return b.w.Delete(ctx, key)
+}
+
+func (b *behindLayer) Name() string {
+	return b.name
+}
+
+func (b *behindLayer) Close() error {
+	return nil
+}
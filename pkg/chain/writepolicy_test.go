@@ -0,0 +1,363 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
+	"cache-chain/pkg/writer"
+)
+
+// newOrigin returns a mock origin backed by a map. Set fails while fail is set.
+func newOrigin(fail *atomic.Bool) (*mock.MockLayer, *sync.Map) {
+	var values sync.Map
+	origin := &mock.MockLayer{
+		NameFunc: func() string { return "origin" },
+		GetFunc: func(ctx context.Context, key string) (interface{}, error) {
+			if v, ok := values.Load(key); ok {
+				return v, nil
+			}
+			return nil, cache.ErrKeyNotFound
+		},
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			if fail.Load() {
+				return errors.New("origin down")
+			}
+			values.Store(key, value)
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, key string) error {
+			values.Delete(key)
+			return nil
+		},
+	}
+	return origin, &values
+}
+
+func TestWritePolicy_String(t *testing.T) {
+	tests := map[WritePolicy]string{
+		WriteAllLayers: "all-layers",
+		WriteThrough:   "write-through",
+		WriteBehind:    "write-behind",
+		WriteAround:    "write-around",
+		WritePolicy(9): "WritePolicy(9)",
+	}
+	for policy, want := range tests {
+		if got := policy.String(); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestNewWithConfig_WritePolicyNeedsOrigin(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	defer l1.Close()
+
+	if _, err := NewWithConfig(ChainConfig{WritePolicy: WriteThrough}, l1); err == nil {
+		t.Error("Expected error for a write policy without origin layer")
+	}
+}
+
+func TestChain_WriteThrough(t *testing.T) {
+	var fail atomic.Bool
+	origin, values := newOrigin(&fail)
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{WritePolicy: WriteThrough}, l1, origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Set(ctx, "key", "v1", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, _ := values.Load("key"); v != "v1" {
+		t.Errorf("Expected origin to hold v1, got %v", v)
+	}
+	if v, _ := l1.Get(ctx, "key"); v != "v1" {
+		t.Errorf("Expected L1 to hold v1, got %v", v)
+	}
+
+	// A failed origin write leaves the caches untouched
+	fail.Store(true)
+	err = c.Set(ctx, "key", "v2", time.Minute)
+	if !errors.Is(err, ErrOriginWrite) {
+		t.Fatalf("Expected ErrOriginWrite, got %v", err)
+	}
+	if v, _ := l1.Get(ctx, "key"); v != "v1" {
+		t.Errorf("Expected L1 to still hold v1, got %v", v)
+	}
+}
+
+func TestChain_WriteThrough_LoadSkipsOrigin(t *testing.T) {
+	var fail atomic.Bool
+	origin, _ := newOrigin(&fail)
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{WritePolicy: WriteThrough}, l1, origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	_, err = c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return "loaded", time.Minute, nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	if v, _ := l1.Get(ctx, "key"); v != "loaded" {
+		t.Errorf("Expected L1 to hold loaded value, got %v", v)
+	}
+	if origin.SetCalls() != 0 {
+		t.Errorf("Expected loads not to write the origin, got %d sets", origin.SetCalls())
+	}
+}
+
+func TestChain_WriteBehind(t *testing.T) {
+	blocker := make(chan struct{})
+	var once sync.Once
+	var mu sync.Mutex
+	writes := make([]interface{}, 0)
+
+	origin := &mock.MockLayer{
+		NameFunc: func() string { return "origin" },
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			once.Do(func() { <-blocker })
+			mu.Lock()
+			defer mu.Unlock()
+			writes = append(writes, value)
+			return nil
+		},
+	}
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{
+		WritePolicy: WriteBehind,
+		WriteBehind: writer.AsyncWriterConfig{QueueSize: 10},
+	}, l1, origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if err := c.Set(ctx, "key", i, time.Minute); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+		// The cache is written immediately
+		if v, _ := l1.Get(ctx, "key"); v != i {
+			t.Errorf("Expected L1 to hold %d, got %v", i, v)
+		}
+		if i == 0 {
+			// Let the worker pick up the first write and block on it
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	close(blocker)
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The first write was in flight; the others coalesce into the latest
+	if len(writes) != 2 || writes[0] != 0 || writes[1] != 4 {
+		t.Errorf("Expected origin writes [0 4], got %v", writes)
+	}
+}
+
+func TestChain_WriteBehind_CloseDrains(t *testing.T) {
+	var fail atomic.Bool
+	origin, values := newOrigin(&fail)
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{WritePolicy: WriteBehind}, l1, origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+
+	ctx := context.Background()
+	c.Set(ctx, "a", "1", time.Minute)
+	c.Set(ctx, "b", "2", time.Minute)
+	c.Delete(ctx, "a")
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, ok := values.Load("a"); ok {
+		t.Error("Expected a to be deleted from origin")
+	}
+	if v, _ := values.Load("b"); v != "2" {
+		t.Errorf("Expected origin to hold b, got %v", v)
+	}
+}
+
+func TestChain_Flush(t *testing.T) {
+	var fail atomic.Bool
+	slow, values := newOrigin(&fail)
+	set := slow.SetFunc
+	slow.SetFunc = func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+		time.Sleep(20 * time.Millisecond)
+		return set(ctx, key, value, ttl)
+	}
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{WritePolicy: WriteBehind}, l1, slow)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(ctx, key, key, time.Minute)
+	}
+
+	flushCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := c.Flush(flushCtx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if v, _ := values.Load(key); v != key {
+			t.Errorf("Expected origin to hold %s after Flush, got %v", key, v)
+		}
+	}
+}
+
+func TestChain_WriteAround(t *testing.T) {
+	var fail atomic.Bool
+	origin, values := newOrigin(&fail)
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{WritePolicy: WriteAround}, l1, origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	l1.Set(ctx, "key", "old", time.Minute)
+
+	if err := c.Set(ctx, "key", "new", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, _ := values.Load("key"); v != "new" {
+		t.Errorf("Expected origin to hold new, got %v", v)
+	}
+	if _, err := l1.Get(ctx, "key"); err != cache.ErrKeyNotFound {
+		t.Errorf("Expected key to be evicted from L1, got %v", err)
+	}
+
+	// The next read loads the new value from the origin
+	if v, err := c.Get(ctx, "key"); err != nil || v != "new" {
+		t.Errorf("Expected new from origin, got %v (%v)", v, err)
+	}
+}
+
+func TestChain_WritePolicy_SetMulti(t *testing.T) {
+	var fail atomic.Bool
+	origin, values := newOrigin(&fail)
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+
+	c, err := NewWithConfig(ChainConfig{WritePolicy: WriteThrough}, l1, origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	items := map[string]interface{}{"a": 1, "b": 2}
+	if err := c.SetMulti(ctx, items, time.Minute); err != nil {
+		t.Fatalf("SetMulti failed: %v", err)
+	}
+	for key, want := range items {
+		if v, _ := values.Load(key); v != want {
+			t.Errorf("Expected origin to hold %s=%v, got %v", key, want, v)
+		}
+		if v, _ := l1.Get(ctx, key); v != want {
+			t.Errorf("Expected L1 to hold %s=%v, got %v", key, want, v)
+		}
+	}
+
+	fail.Store(true)
+	err = c.SetMulti(ctx, map[string]interface{}{"c": 3}, time.Minute)
+	if !errors.Is(err, ErrOriginWrite) {
+		t.Fatalf("Expected ErrOriginWrite, got %v", err)
+	}
+	if _, err := l1.Get(ctx, "c"); err != cache.ErrKeyNotFound {
+		t.Errorf("Expected c not to be cached, got %v", err)
+	}
+}
+
+func TestChain_WriteBehind_Journal(t *testing.T) {
+	dir := t.TempDir()
+	crashed := t.TempDir()
+
+	// The origin hangs, so writes pile up in the journal
+	release := make(chan struct{})
+	stuck := &mock.MockLayer{
+		NameFunc: func() string { return "origin" },
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			<-release
+			return nil
+		},
+	}
+	config := ChainConfig{
+		WritePolicy: WriteBehind,
+		WriteBehind: writer.AsyncWriterConfig{Journal: &writer.JournalConfig{Dir: dir}},
+	}
+
+	c, err := NewWithConfig(config, memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"}), stuck)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer c.Close()
+	defer close(release)
+
+	ctx := context.Background()
+	c.Set(ctx, "a", "1", time.Minute)
+	c.Set(ctx, "b", "2", time.Minute)
+
+	// Restart from what the crashed process left on disk
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, e.Name()))
+		os.WriteFile(filepath.Join(crashed, e.Name()), data, 0o644)
+	}
+
+	var fail atomic.Bool
+	origin, values := newOrigin(&fail)
+	config.WriteBehind.Journal = &writer.JournalConfig{Dir: crashed}
+	restarted, err := NewWithConfig(config, memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"}), origin)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer restarted.Close()
+
+	flushCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := restarted.Flush(flushCtx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if v, _ := values.Load(key); v != want {
+			t.Errorf("Expected origin to hold %s=%s after restart, got %v", key, want, v)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package chain
+
+import (
+	"math"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+// shouldRefreshEarly implements XFetch (Vattani et al., "Optimal Probabilistic
+// Cache Stampede Prevention"). Each read refreshes the entry with a
+// probability that grows as expiry approaches, scaled by how long the value
+// took to load and by EarlyExpirationBeta:
+//
+//	now - loadDuration * beta * ln(rand()) >= expiry
+//
+// Concurrent instances thus spread their refreshes ahead of expiry instead of
+// all missing at once. Entries without a recorded load duration or expiry
+// never refresh early.
+func (c *Chain) shouldRefreshEarly(entry *cache.CacheEntry) bool {
+	if c.earlyExpirationBeta <= 0 || entry.LoadDuration <= 0 {
+		return false
+	}
+
+	// Refresh ahead of the soft TTL when set, so the value never goes stale
+	expiry := entry.StaleAt
+	if expiry.IsZero() {
+		expiry = entry.ExpiresAt
+	}
+	if expiry.IsZero() {
+		return false
+	}
+
+	// 1-rand is in (0, 1], so the logarithm is finite
+	gap := -float64(entry.LoadDuration) * c.earlyExpirationBeta * math.Log(1-c.random())
+	return gap >= float64(time.Until(expiry))
+}
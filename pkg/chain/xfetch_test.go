@@ -0,0 +1,122 @@
+package chain
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/memory"
+)
+
+func TestChain_EarlyExpiration_RefreshesBeforeExpiry(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := NewWithConfig(ChainConfig{EarlyExpirationBeta: 1.0}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var loads int32
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		n := atomic.AddInt32(&loads, 1)
+		time.Sleep(10 * time.Millisecond)
+		return n, time.Hour, nil
+	}
+
+	ctx := context.Background()
+	if _, err := c.GetOrLoad(ctx, "key", loader); err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	// The load duration is recorded with the entry
+	entry, err := l1.GetEntry(ctx, "key")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if entry.LoadDuration < 10*time.Millisecond {
+		t.Errorf("Expected load duration >= 10ms, got %v", entry.LoadDuration)
+	}
+
+	// A draw close to 1 makes the gap exceed the remaining hour
+	c.random = func() float64 { return 1 - 1e-300 }
+
+	value, err := c.GetOrLoad(ctx, "key", loader)
+	if err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+	if value != int32(1) {
+		t.Errorf("Expected cached value to be served during refresh, got %v", value)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&loads) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected early background refresh")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestChain_EarlyExpiration_NotTriggeredFarFromExpiry(t *testing.T) {
+	l1 := memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"})
+	c, err := NewWithConfig(ChainConfig{EarlyExpirationBeta: 1.0}, l1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var loads int32
+	c.RegisterLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&loads, 1)
+		return "fresh", time.Hour, nil
+	})
+
+	ctx := context.Background()
+	if _, err := c.GetOrLoad(ctx, "key", func(ctx context.Context) (interface{}, time.Duration, error) {
+		time.Sleep(time.Millisecond)
+		return "value", time.Hour, nil
+	}); err != nil {
+		t.Fatalf("GetOrLoad failed: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if _, err := c.Get(ctx, "key"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	c.refreshWG.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 0 {
+		t.Errorf("Expected no early refresh an hour before expiry, got %d", n)
+	}
+}
+
+func TestChain_EarlyExpiration_Disabled(t *testing.T) {
+	c, err := New(memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "L1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.random = func() float64 { return 1 - 1e-300 }
+
+	entry := &cache.CacheEntry{
+		ExpiresAt:    time.Now().Add(time.Millisecond),
+		LoadDuration: time.Second,
+	}
+	if c.shouldRefreshEarly(entry) {
+		t.Error("Expected no early refresh with beta 0")
+	}
+
+	c.earlyExpirationBeta = 1.0
+	if !c.shouldRefreshEarly(entry) {
+		t.Error("Expected early refresh near expiry")
+	}
+
+	// Without a recorded load duration there is nothing to scale by
+	entry.LoadDuration = 0
+	if c.shouldRefreshEarly(entry) {
+		t.Error("Expected no early refresh without load duration")
+	}
+}
@@ -18,6 +18,11 @@ type MemoryCollector struct {
 	chainHits        int64
 	chainMisses      int64
 	chainHitsByLayer map[int]int64
+
+	// Origin loads (Chain.GetOrLoad)
+	loads         int64
+	loadErrors    int64
+	loadLatencies []time.Duration
 }
 
 // LayerMetrics holds metrics for a single cache layer.
@@ -32,6 +37,11 @@ type LayerMetrics struct {
 	// Error types (by error_type label)
 	ErrorsByType map[string]int64
 
+	// Compression
+	CompressedValues int64
+	RawBytes         int64
+	StoredBytes      int64
+
 	// Circuit breaker
 	CircuitState metrics.CircuitState
 	CircuitOpens int64
@@ -127,6 +137,18 @@ func (mc *MemoryCollector) RecordError(layer, operation, errorType string) {
 	lm.ErrorsByType[errorType]++
 }
 
+// RecordCompression records the sizes of a compressed value.
+func (mc *MemoryCollector) RecordCompression(layer string, rawBytes, storedBytes int) {
+	lm := mc.getOrCreateLayer(layer)
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	lm.CompressedValues++
+	lm.RawBytes += int64(rawBytes)
+	lm.StoredBytes += int64(storedBytes)
+}
+
 // RecordCircuitState records the current circuit breaker state.
 func (mc *MemoryCollector) RecordCircuitState(layer string, state metrics.CircuitState) {
 	lm := mc.getOrCreateLayer(layer)
@@ -190,12 +212,26 @@ func (mc *MemoryCollector) RecordChainGet(hit bool, layerIndex int, totalDuratio
 	}
 }
 
+// RecordLoad records an origin load performed after a full-chain miss.
+func (mc *MemoryCollector) RecordLoad(success bool, duration time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.loads++
+	if !success {
+		mc.loadErrors++
+	}
+	mc.loadLatencies = append(mc.loadLatencies, duration)
+}
+
 // Snapshot returns a copy of the current metrics.
 type Snapshot struct {
 	LayerMetrics     map[string]LayerMetrics
 	ChainHits        int64
 	ChainMisses      int64
 	ChainHitsByLayer map[int]int64
+	Loads            int64
+	LoadErrors       int64
 }
 
 // Snapshot returns a copy of the current metrics state.
@@ -208,6 +244,8 @@ func (mc *MemoryCollector) Snapshot() Snapshot {
 		ChainHits:        mc.chainHits,
 		ChainMisses:      mc.chainMisses,
 		ChainHitsByLayer: make(map[int]int64),
+		Loads:            mc.loads,
+		LoadErrors:       mc.loadErrors,
 	}
 
 	// Deep copy layer metrics
@@ -232,6 +270,9 @@ func (mc *MemoryCollector) Reset() {
 	mc.chainHits = 0
 	mc.chainMisses = 0
 	mc.chainHitsByLayer = make(map[int]int64)
+	mc.loads = 0
+	mc.loadErrors = 0
+	mc.loadLatencies = nil
 }
 
 // GetLayerMetrics returns the metrics for a specific layer.
@@ -13,6 +13,9 @@ type MetricsCollector interface {
 	RecordDelete(layer string, success bool, duration time.Duration)
 	RecordError(layer, operation, errorType string)
 
+	// Serialization
+	RecordCompression(layer string, rawBytes, storedBytes int)
+
 	// Circuit breaker
 	RecordCircuitState(layer string, state CircuitState)
 
@@ -25,6 +28,14 @@ type MetricsCollector interface {
 	RecordChainGet(hit bool, layerIndex int, totalDuration time.Duration)
 }
 
+// LoadMetricsCollector is an optional extension of MetricsCollector for
+// collectors that also record origin loads (Chain.GetOrLoad and loaders).
+// The chain checks for it with a type assertion, so existing collectors
+// keep working without it.
+type LoadMetricsCollector interface {
+	RecordLoad(success bool, duration time.Duration)
+}
+
 // CircuitState represents the state of a circuit breaker.
 type CircuitState int
 
@@ -67,6 +78,9 @@ func (NoOpCollector) RecordDelete(layer string, success bool, duration time.Dura
 // RecordError does nothing.
 func (NoOpCollector) RecordError(layer, operation, errorType string) {}
 
+// RecordCompression does nothing.
+func (NoOpCollector) RecordCompression(layer string, rawBytes, storedBytes int) {}
+
 // RecordCircuitState does nothing.
 func (NoOpCollector) RecordCircuitState(layer string, state CircuitState) {}
 
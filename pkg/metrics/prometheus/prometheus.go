@@ -19,6 +19,10 @@ type PrometheusCollector struct {
 	cacheDeletes *prometheus.CounterVec
 	cacheErrors  *prometheus.CounterVec
 
+	// Compression
+	compressionBytesSaved *prometheus.CounterVec
+	compressionRatio      *prometheus.HistogramVec
+
 	// Circuit breaker
 	circuitOpens *prometheus.CounterVec
 	circuitState *prometheus.GaugeVec
@@ -39,6 +43,10 @@ type PrometheusCollector struct {
 	chainHits    *prometheus.CounterVec
 	chainMisses  *prometheus.CounterVec
 	chainLatency *prometheus.HistogramVec
+
+	// Origin loads
+	loads       *prometheus.CounterVec
+	loadLatency *prometheus.HistogramVec
 }
 
 // NewPrometheusCollector creates a new Prometheus metrics collector.
@@ -85,6 +93,23 @@ func NewPrometheusCollector(namespace string) *PrometheusCollector {
 			},
 			[]string{"layer", "operation", "error_type"},
 		),
+		compressionBytesSaved: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "compression_bytes_saved_total",
+				Help:      "Total number of bytes saved by value compression per layer",
+			},
+			[]string{"layer"},
+		),
+		compressionRatio: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "compression_ratio",
+				Help:      "Ratio of stored to raw size for compressed values",
+				Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+			},
+			[]string{"layer"},
+		),
 		circuitOpens: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -194,6 +219,23 @@ func NewPrometheusCollector(namespace string) *PrometheusCollector {
 			},
 			[]string{},
 		),
+		loads: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "chain_loads_total",
+				Help:      "Total number of origin loads after a full-chain miss",
+			},
+			[]string{"status"},
+		),
+		loadLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "chain_load_duration_seconds",
+				Help:      "Origin load latency after a full-chain miss",
+				Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 15),
+			},
+			[]string{"status"},
+		),
 	}
 
 	return pc
@@ -207,6 +249,8 @@ func (pc *PrometheusCollector) Register(registry *prometheus.Registry) error {
 		pc.cacheSets,
 		pc.cacheDeletes,
 		pc.cacheErrors,
+		pc.compressionBytesSaved,
+		pc.compressionRatio,
 		pc.circuitOpens,
 		pc.circuitState,
 		pc.queueDepth,
@@ -220,6 +264,8 @@ func (pc *PrometheusCollector) Register(registry *prometheus.Registry) error {
 		pc.chainLatency,
 		pc.chainHits,
 		pc.chainMisses,
+		pc.loads,
+		pc.loadLatency,
 	}
 
 	for _, collector := range collectors {
@@ -238,6 +284,8 @@ func (pc *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
 	pc.cacheSets.Describe(ch)
 	pc.cacheDeletes.Describe(ch)
 	pc.cacheErrors.Describe(ch)
+	pc.compressionBytesSaved.Describe(ch)
+	pc.compressionRatio.Describe(ch)
 	pc.circuitOpens.Describe(ch)
 	pc.circuitState.Describe(ch)
 	pc.queueDepth.Describe(ch)
@@ -251,6 +299,8 @@ func (pc *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
 	pc.chainHits.Describe(ch)
 	pc.chainMisses.Describe(ch)
 	pc.chainLatency.Describe(ch)
+	pc.loads.Describe(ch)
+	pc.loadLatency.Describe(ch)
 }
 
 // Collect implements prometheus.Collector interface
@@ -260,6 +310,8 @@ func (pc *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
 	pc.cacheSets.Collect(ch)
 	pc.cacheDeletes.Collect(ch)
 	pc.cacheErrors.Collect(ch)
+	pc.compressionBytesSaved.Collect(ch)
+	pc.compressionRatio.Collect(ch)
 	pc.circuitOpens.Collect(ch)
 	pc.circuitState.Collect(ch)
 	pc.queueDepth.Collect(ch)
@@ -273,6 +325,8 @@ func (pc *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
 	pc.chainHits.Collect(ch)
 	pc.chainMisses.Collect(ch)
 	pc.chainLatency.Collect(ch)
+	pc.loads.Collect(ch)
+	pc.loadLatency.Collect(ch)
 }
 
 // RecordGet records a cache get operation.
@@ -303,6 +357,15 @@ func (pc *PrometheusCollector) RecordDelete(layer string, success bool, duration
 	pc.deleteLatency.WithLabelValues(layer).Observe(duration.Seconds())
 }
 
+// RecordCompression records the sizes of a compressed value.
+func (pc *PrometheusCollector) RecordCompression(layer string, rawBytes, storedBytes int) {
+	if rawBytes <= 0 {
+		return
+	}
+	pc.compressionBytesSaved.WithLabelValues(layer).Add(float64(rawBytes - storedBytes))
+	pc.compressionRatio.WithLabelValues(layer).Observe(float64(storedBytes) / float64(rawBytes))
+}
+
 // RecordCircuitState records the current circuit breaker state.
 func (pc *PrometheusCollector) RecordCircuitState(layer string, state metrics.CircuitState) {
 	pc.circuitState.WithLabelValues(layer).Set(float64(state))
@@ -348,3 +411,13 @@ func (pc *PrometheusCollector) RecordChainGet(hit bool, layerIndex int, totalDur
 	}
 	pc.chainLatency.WithLabelValues(hitLabel).Observe(totalDuration.Seconds())
 }
+
+// RecordLoad records an origin load performed after a full-chain miss.
+func (pc *PrometheusCollector) RecordLoad(success bool, duration time.Duration) {
+	status := "success"
+	if !success {
+		status = "error"
+	}
+	pc.loads.WithLabelValues(status).Inc()
+	pc.loadLatency.WithLabelValues(status).Observe(duration.Seconds())
+}
@@ -0,0 +1,96 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+// GetMulti retrieves several keys with the same protection as Get, counting
+// the batch as a single operation. Layers implementing cache.BatchCacheLayer
+// are called natively; others are read key by key through a cache.BatchAdapter.
+// Keys found before an error are still returned.
+func (rl *ResilientLayer) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	bl, ok := rl.layer.(cache.BatchCacheLayer)
+	if !ok {
+		return cache.NewBatchAdapter(rl).GetMulti(ctx, keys)
+	}
+
+	var found map[string]interface{}
+	err := rl.getMulti(ctx, keys, func(ctx context.Context) error {
+		values, err := bl.GetMulti(ctx, keys)
+		found = values
+		return err
+	})
+	return found, err
+}
+
+// GetMultiEntries retrieves several entries with the same protection as
+// GetMulti. Layers that don't implement cache.BatchEntryLayer are read key by
+// key with GetEntry through a cache.BatchAdapter.
+func (rl *ResilientLayer) GetMultiEntries(ctx context.Context, keys []string) (map[string]*cache.CacheEntry, error) {
+	bl, ok := rl.layer.(cache.BatchEntryLayer)
+	if !ok {
+		return cache.NewBatchAdapter(rl).GetMultiEntries(ctx, keys)
+	}
+
+	var found map[string]*cache.CacheEntry
+	err := rl.getMulti(ctx, keys, func(ctx context.Context) error {
+		entries, err := bl.GetMultiEntries(ctx, keys)
+		found = entries
+		return err
+	})
+	return found, err
+}
+
+// getMulti runs a batch read like get. Per-key failures reported in a
+// *cache.BatchError are passed through without counting towards the circuit
+// breaker, unless every key failed: then the layer itself is failing.
+func (rl *ResilientLayer) getMulti(ctx context.Context, keys []string, op func(ctx context.Context) error) error {
+	var keyErrs error
+	_, err := rl.get(ctx, batchLabel(keys), func(ctx context.Context) (interface{}, error) {
+		err := op(ctx)
+		if batchErr, ok := cache.AsBatchError(err); ok && len(batchErr.Errors) < len(keys) {
+			keyErrs = err
+			return nil, nil
+		}
+		return nil, err
+	})
+	if err != nil {
+		return err
+	}
+	return keyErrs
+}
+
+// SetMulti stores several values with the same protection as Set.
+// Layers without native batch support are written key by key.
+func (rl *ResilientLayer) SetMulti(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	bl, ok := rl.layer.(cache.BatchCacheLayer)
+	if !ok {
+		return cache.NewBatchAdapter(rl).SetMulti(ctx, items, ttl)
+	}
+
+	return rl.set(ctx, ttl, func(ctx context.Context) error {
+		return bl.SetMulti(ctx, items, ttl)
+	})
+}
+
+// DeleteMulti removes several keys with the same protection as Delete.
+// Layers without native batch support are deleted key by key.
+func (rl *ResilientLayer) DeleteMulti(ctx context.Context, keys []string) error {
+	bl, ok := rl.layer.(cache.BatchCacheLayer)
+	if !ok {
+		return cache.NewBatchAdapter(rl).DeleteMulti(ctx, keys)
+	}
+
+	return rl.delete(ctx, batchLabel(keys), func(ctx context.Context) error {
+		return bl.DeleteMulti(ctx, keys)
+	})
+}
+
+// batchLabel describes a batch in logs.
+func batchLabel(keys []string) string {
+	return fmt.Sprintf("batch of %d keys", len(keys))
+}
@@ -0,0 +1,52 @@
+package resilience
+
+import (
+	"context"
+
+	"cache-chain/pkg/cache"
+
+	"github.com/sony/gobreaker"
+)
+
+// SetEntryFenced stores a lease-fenced entry with the same protection as Set.
+// Returns cache.ErrNotSupported if the underlying layer doesn't implement
+// cache.FencedLayer.
+func (rl *ResilientLayer) SetEntryFenced(ctx context.Context, entry *cache.CacheEntry, fenceKey string, token uint64) (bool, error) {
+	fl, ok := rl.layer.(cache.FencedLayer)
+	if !ok {
+		return false, cache.ErrNotSupported
+	}
+
+	var stored bool
+	err := rl.set(ctx, entry.TimeToLive(), func(ctx context.Context) error {
+		var err error
+		stored, err = fl.SetEntryFenced(ctx, entry, fenceKey, token)
+		return err
+	})
+	return stored, err
+}
+
+// PeekEntry reads an entry like GetEntry, with the timeout, but records no
+// metrics, logs nothing and doesn't count towards the circuit breaker. It is
+// meant for polling, e.g. while another instance loads the key.
+// Returns cache.ErrCircuitOpen while the breaker is open.
+func (rl *ResilientLayer) PeekEntry(ctx context.Context, key string) (*cache.CacheEntry, error) {
+	if rl.cb.State() == gobreaker.StateOpen {
+		return nil, cache.ErrCircuitOpen
+	}
+
+	if rl.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rl.timeout)
+		defer cancel()
+	}
+
+	if el, ok := rl.layer.(cache.EntryLayer); ok {
+		return el.GetEntry(ctx, key)
+	}
+	value, err := rl.layer.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &cache.CacheEntry{Key: key, Value: value}, nil
+}
@@ -101,6 +101,33 @@ func (rl *ResilientLayer) Name() string {
 // Get retrieves a value from the cache with timeout and circuit breaker protection.
 // Note: ErrKeyNotFound (cache miss) is NOT considered a failure for the circuit breaker.
 func (rl *ResilientLayer) Get(ctx context.Context, key string) (interface{}, error) {
+	return rl.get(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return rl.layer.Get(ctx, key)
+	})
+}
+
+// GetEntry retrieves a value with its metadata, with the same protection as Get.
+// If the underlying layer doesn't implement cache.EntryLayer, the value is
+// fetched with Get and returned in an entry without metadata.
+func (rl *ResilientLayer) GetEntry(ctx context.Context, key string) (*cache.CacheEntry, error) {
+	result, err := rl.get(ctx, key, func(ctx context.Context) (interface{}, error) {
+		if el, ok := rl.layer.(cache.EntryLayer); ok {
+			return el.GetEntry(ctx, key)
+		}
+		value, err := rl.layer.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &cache.CacheEntry{Key: key, Value: value}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*cache.CacheEntry), nil
+}
+
+// get runs a read operation with timeout and circuit breaker protection.
+func (rl *ResilientLayer) get(ctx context.Context, key string, op func(ctx context.Context) (interface{}, error)) (interface{}, error) {
 	start := time.Now()
 	layerName := rl.layer.Name()
 
@@ -115,7 +142,7 @@ func (rl *ResilientLayer) Get(ctx context.Context, key string) (interface{}, err
 	// IMPORTANT: We need to distinguish between cache misses (not a failure) and real errors
 	var actualErr error
 	result, err := rl.cb.Execute(func() (interface{}, error) {
-		value, err := rl.layer.Get(ctx, key)
+		value, err := op(ctx)
 		actualErr = err
 		// Don't treat cache misses as circuit breaker failures
 		if cache.IsNotFound(err) {
@@ -175,6 +202,34 @@ func (rl *ResilientLayer) Get(ctx context.Context, key string) (interface{}, err
 
 // Set stores a value in the cache with timeout and circuit breaker protection.
 func (rl *ResilientLayer) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return rl.set(ctx, ttl, func(ctx context.Context) error {
+		return rl.layer.Set(ctx, key, value, ttl)
+	})
+}
+
+// SetEntry stores a value with its metadata, with the same protection as Set.
+// If the underlying layer doesn't implement cache.EntryLayer, the value is
+// stored with Set until entry.ExpiresAt and the metadata is dropped.
+func (rl *ResilientLayer) SetEntry(ctx context.Context, entry *cache.CacheEntry) error {
+	el, ok := rl.layer.(cache.EntryLayer)
+	if !ok {
+		var ttl time.Duration
+		if !entry.ExpiresAt.IsZero() {
+			ttl = time.Until(entry.ExpiresAt)
+			if ttl <= 0 {
+				return rl.Delete(ctx, entry.Key)
+			}
+		}
+		return rl.Set(ctx, entry.Key, entry.Value, ttl)
+	}
+
+	return rl.set(ctx, entry.TimeToLive(), func(ctx context.Context) error {
+		return el.SetEntry(ctx, entry)
+	})
+}
+
+// set runs a write operation with timeout and circuit breaker protection.
+func (rl *ResilientLayer) set(ctx context.Context, ttl time.Duration, op func(ctx context.Context) error) error {
 	start := time.Now()
 	layerName := rl.layer.Name()
 
@@ -187,7 +242,7 @@ func (rl *ResilientLayer) Set(ctx context.Context, key string, value interface{}
 
 	// Execute through circuit breaker
 	_, err := rl.cb.Execute(func() (interface{}, error) {
-		return nil, rl.layer.Set(ctx, key, value, ttl)
+		return nil, op(ctx)
 	})
 
 	// Record metrics
@@ -232,6 +287,13 @@ func (rl *ResilientLayer) Set(ctx context.Context, key string, value interface{}
 
 // Delete removes a value from the cache with timeout and circuit breaker protection.
 func (rl *ResilientLayer) Delete(ctx context.Context, key string) error {
+	return rl.delete(ctx, key, func(ctx context.Context) error {
+		return rl.layer.Delete(ctx, key)
+	})
+}
+
+// delete runs a delete operation with timeout and circuit breaker protection.
+func (rl *ResilientLayer) delete(ctx context.Context, key string, op func(ctx context.Context) error) error {
 	start := time.Now()
 	layerName := rl.layer.Name()
 
@@ -244,7 +306,7 @@ func (rl *ResilientLayer) Delete(ctx context.Context, key string) error {
 
 	// Execute through circuit breaker
 	_, err := rl.cb.Execute(func() (interface{}, error) {
-		return nil, rl.layer.Delete(ctx, key)
+		return nil, op(ctx)
 	})
 
 	// Record metrics
@@ -8,6 +8,7 @@ import (
 
 	"cache-chain/pkg/cache"
 	"cache-chain/pkg/cache/memory"
+	"cache-chain/pkg/cache/mock"
 )
 
 func TestNewResilientLayer(t *testing.T) {
@@ -290,3 +291,134 @@ func (f *failingMockLayer) Delete(ctx context.Context, key string) error {
 func (f *failingMockLayer) Close() error {
 	return nil
 }
+
+func TestResilientLayer_Entry(t *testing.T) {
+	ctx := context.Background()
+	entry := &cache.CacheEntry{
+		Key:       "key",
+		Value:     "value",
+		StaleAt:   time.Now().Add(time.Minute),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	// Layers with metadata support keep it
+	rl := NewResilientLayer(memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "test"}), DefaultResilientConfig())
+	defer rl.Close()
+
+	if err := rl.SetEntry(ctx, entry); err != nil {
+		t.Fatalf("SetEntry failed: %v", err)
+	}
+	got, err := rl.GetEntry(ctx, "key")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if got.Value != "value" || !got.StaleAt.Equal(entry.StaleAt) {
+		t.Errorf("Expected entry with metadata, got %+v", got)
+	}
+
+	// Other layers fall back to plain values
+	stored := map[string]interface{}{}
+	layer := mock.NewMockLayerWithDefaults("mock")
+	layer.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		if value, ok := stored[key]; ok {
+			return value, nil
+		}
+		return nil, cache.ErrKeyNotFound
+	}
+	layer.SetFunc = func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+		stored[key] = value
+		return nil
+	}
+
+	plain := NewResilientLayer(layer, DefaultResilientConfig())
+	defer plain.Close()
+
+	if err := plain.SetEntry(ctx, entry); err != nil {
+		t.Fatalf("SetEntry failed: %v", err)
+	}
+	got, err = plain.GetEntry(ctx, "key")
+	if err != nil {
+		t.Fatalf("GetEntry failed: %v", err)
+	}
+	if got.Value != "value" || !got.StaleAt.IsZero() {
+		t.Errorf("Expected plain entry without metadata, got %+v", got)
+	}
+
+	if _, err := plain.GetEntry(ctx, "missing"); err == nil {
+		t.Error("Expected miss for missing key")
+	}
+}
+
+func TestResilientLayer_Batch(t *testing.T) {
+	ctx := context.Background()
+
+	// Native batch layers and plain layers behave the same
+	layers := map[string]*ResilientLayer{
+		"native": NewResilientLayer(cache.NewBatchAdapter(memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "native"})), DefaultResilientConfig()),
+		"plain":  NewResilientLayer(memory.NewMemoryCache(memory.MemoryCacheConfig{Name: "plain"}), DefaultResilientConfig()),
+	}
+
+	for name, rl := range layers {
+		t.Run(name, func(t *testing.T) {
+			defer rl.Close()
+
+			items := map[string]interface{}{"a": 1, "b": 2}
+			if err := rl.SetMulti(ctx, items, time.Minute); err != nil {
+				t.Fatalf("SetMulti failed: %v", err)
+			}
+
+			found, err := rl.GetMulti(ctx, []string{"a", "b", "c"})
+			if err != nil {
+				t.Fatalf("GetMulti failed: %v", err)
+			}
+			if len(found) != 2 || found["a"] != 1 || found["b"] != 2 {
+				t.Errorf("Expected a and b, got %v", found)
+			}
+
+			if err := rl.DeleteMulti(ctx, []string{"a"}); err != nil {
+				t.Fatalf("DeleteMulti failed: %v", err)
+			}
+			if _, err := rl.Get(ctx, "a"); !cache.IsNotFound(err) {
+				t.Errorf("Expected a to be deleted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResilientLayer_BatchKeyErrorsDontTripCircuit(t *testing.T) {
+	ctx := context.Background()
+
+	// A native batch layer on which one key always fails to decode
+	base := mock.NewMockLayer("batch")
+	base.GetFunc = func(ctx context.Context, key string) (interface{}, error) {
+		if key == "corrupt" {
+			return nil, errors.New("failed to unmarshal")
+		}
+		return "value", nil
+	}
+
+	config := DefaultResilientConfig()
+	config.CircuitBreakerConfig.ReadyToTrip = func(counts Counts) bool {
+		return counts.ConsecutiveFailures >= 2
+	}
+	rl := NewResilientLayer(cache.NewBatchAdapter(base), config)
+	defer rl.Close()
+
+	for i := 0; i < 5; i++ {
+		found, err := rl.GetMulti(ctx, []string{"a", "corrupt"})
+		if _, ok := cache.AsBatchError(err); !ok {
+			t.Fatalf("Expected the per-key error to be passed through, got %v", err)
+		}
+		if found["a"] != "value" {
+			t.Errorf("Expected a to be found, got %v", found)
+		}
+	}
+
+	// A batch where every key fails is a layer failure
+	for i := 0; i < 2; i++ {
+		rl.GetMulti(ctx, []string{"corrupt"})
+	}
+	if _, err := rl.GetMulti(ctx, []string{"a"}); !errors.Is(err, cache.ErrCircuitOpen) {
+		t.Errorf("Expected circuit to open after whole-batch failures, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package resilience
+
+import (
+	"context"
+
+	"cache-chain/pkg/cache"
+
+	"github.com/sony/gobreaker"
+	"go.uber.org/zap"
+)
+
+// DeletePrefix removes the keys starting with prefix through the circuit
+// breaker. The layer timeout isn't applied, since walking a large keyspace
+// takes far longer than a single operation; ctx bounds it instead.
+// Returns cache.ErrNotSupported if the underlying layer doesn't implement
+// cache.PrefixLayer.
+func (rl *ResilientLayer) DeletePrefix(ctx context.Context, prefix string, onBatch func(keys []string)) (int, error) {
+	pl, ok := rl.layer.(cache.PrefixLayer)
+	if !ok {
+		return 0, cache.ErrNotSupported
+	}
+
+	var deleted int
+	_, err := rl.cb.Execute(func() (interface{}, error) {
+		n, err := pl.DeletePrefix(ctx, prefix, onBatch)
+		deleted = n
+		return nil, err
+	})
+
+	if err == gobreaker.ErrOpenState {
+		rl.metrics.RecordError(rl.layer.Name(), "delete", "circuit_breaker_open")
+		return 0, cache.ErrCircuitOpen
+	}
+	if err != nil {
+		rl.metrics.RecordError(rl.layer.Name(), "delete", cache.ClassifyError(err))
+		rl.logger.Error("delete prefix failed",
+			zap.String("prefix", prefix),
+			zap.Int("deleted", deleted),
+			zap.Error(err),
+		)
+	}
+	return deleted, err
+}
@@ -0,0 +1,39 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+// SetWithTags stores a tagged value with the same protection as Set.
+// If the underlying layer doesn't implement cache.TaggedLayer, the value is
+// stored with Set and the tags are dropped.
+func (rl *ResilientLayer) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags []string) error {
+	tl, ok := rl.layer.(cache.TaggedLayer)
+	if !ok {
+		return rl.Set(ctx, key, value, ttl)
+	}
+
+	return rl.set(ctx, ttl, func(ctx context.Context) error {
+		return tl.SetWithTags(ctx, key, value, ttl, tags)
+	})
+}
+
+// InvalidateTag removes the entries carrying tag with the same protection as
+// Delete. Layers without a tag index have nothing to invalidate.
+func (rl *ResilientLayer) InvalidateTag(ctx context.Context, tag string) ([]string, error) {
+	tl, ok := rl.layer.(cache.TaggedLayer)
+	if !ok {
+		return nil, nil
+	}
+
+	var keys []string
+	err := rl.delete(ctx, "tag "+tag, func(ctx context.Context) error {
+		invalidated, err := tl.InvalidateTag(ctx, tag)
+		keys = invalidated
+		return err
+	})
+	return keys, err
+}
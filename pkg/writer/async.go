@@ -2,6 +2,10 @@ package writer
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,10 +16,12 @@ import (
 
 // AsyncWriter provides non-blocking cache writes using a worker pool and bounded queue.
 // It prevents cache warm-up operations from blocking Get() calls while maintaining
-// ordering guarantees within the same key.
+// ordering guarantees within the same key: writes are routed by key hash to a
+// fixed worker, and a write of a key still waiting in the queue is replaced
+// by newer writes of that key, so the latest value always wins.
 type AsyncWriter struct {
 	layer      cache.CacheLayer
-	queue      chan writeOp
+	lanes      []*lane // one queue per worker
 	workers    int
 	wg         sync.WaitGroup
 	ctx        context.Context
@@ -24,10 +30,22 @@ type AsyncWriter struct {
 	metrics    metrics.MetricsCollector
 	layerName  string
 
+	// closed stops new writes while Close drains the queue; cancelling ctx
+	// abandons what is left
+	closed chan struct{}
+
+	// inflight counts the writes accepted but not yet applied
+	inflight *inflight
+
+	// journal backs the queue when configured (nil otherwise)
+	journal *journal
+
 	// Statistics (accessed atomically)
-	droppedWrites int64
-	totalWrites   int64
-	failedWrites  int64
+	droppedWrites   int64
+	totalWrites     int64
+	failedWrites    int64
+	coalescedWrites int64
+	deadLetters     int64
 
 	// Metrics ticker for periodic queue depth reporting
 	metricsTicker *time.Ticker
@@ -39,12 +57,16 @@ type writeOp struct {
 	key       string
 	value     interface{}
 	ttl       time.Duration
-	timestamp time.Time // For ordering verification
+	entry     *cache.CacheEntry // Set instead of value/ttl for entry writes
+	delete    bool              // Set for deletes
+	timestamp time.Time         // When the write was requested
+	seqs      []uint64          // Journal sequence numbers to acknowledge once applied
 }
 
 // AsyncWriterConfig configures the async writer behavior.
 type AsyncWriterConfig struct {
-	// QueueSize is the bounded queue size (default: 1000)
+	// QueueSize is the bounded queue size, split evenly between the
+	// workers' queues (default: 1000)
 	QueueSize int
 
 	// Workers is the number of concurrent workers (default: 2)
@@ -53,6 +75,28 @@ type AsyncWriterConfig struct {
 	// MaxWaitTime is the max time to wait if queue is full.
 	// 0 means drop immediately (default: 10ms)
 	MaxWaitTime time.Duration
+
+	// OpTimeout bounds each attempt to apply a write (default: 5s)
+	OpTimeout time.Duration
+
+	// MaxAttempts is how many times a failing write is tried (default: 1, no retries)
+	MaxAttempts int
+
+	// RetryBackoff is the delay before the first retry; it doubles with each
+	// further retry, and a random jitter of up to half of it is subtracted
+	// (default: 100ms)
+	RetryBackoff time.Duration
+
+	// MaxRetryBackoff caps the delay between retries (default: 5s)
+	MaxRetryBackoff time.Duration
+
+	// OnDeadLetter is called with each write given up on (optional). It runs
+	// on the worker, so it should hand the write off rather than block.
+	OnDeadLetter func(DeadLetter)
+
+	// Journal backs the queue with an on-disk journal (optional, see
+	// JournalConfig). Journaled writers must be created with OpenAsyncWriter.
+	Journal *JournalConfig
 }
 
 // NewAsyncWriter creates a new async writer with bounded queue and worker pool.
@@ -62,7 +106,20 @@ func NewAsyncWriter(layer cache.CacheLayer, config AsyncWriterConfig) *AsyncWrit
 }
 
 // NewAsyncWriterWithMetrics creates a new async writer with custom metrics collector.
+// It panics if config.Journal is set and the journal can't be opened; use
+// OpenAsyncWriter to handle the error.
 func NewAsyncWriterWithMetrics(layer cache.CacheLayer, config AsyncWriterConfig, metricsCollector metrics.MetricsCollector) *AsyncWriter {
+	w, err := OpenAsyncWriter(layer, config, metricsCollector)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// OpenAsyncWriter creates a new async writer with custom metrics collector,
+// opening its journal if config.Journal is set. Writes left in the journal
+// by an earlier writer are applied first.
+func OpenAsyncWriter(layer cache.CacheLayer, config AsyncWriterConfig, metricsCollector metrics.MetricsCollector) (*AsyncWriter, error) {
 	// Apply defaults
 	if config.QueueSize <= 0 {
 		config.QueueSize = 1000
@@ -73,15 +130,48 @@ func NewAsyncWriterWithMetrics(layer cache.CacheLayer, config AsyncWriterConfig,
 	if config.MaxWaitTime == 0 {
 		config.MaxWaitTime = 10 * time.Millisecond
 	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 100 * time.Millisecond
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = 5 * time.Second
+	}
+	if config.MaxRetryBackoff < config.RetryBackoff {
+		config.MaxRetryBackoff = config.RetryBackoff
+	}
+	if config.OpTimeout <= 0 {
+		config.OpTimeout = 5 * time.Second
+	}
+
+	var j *journal
+	if config.Journal != nil {
+		var err error
+		if j, err = openJournal(*config.Journal); err != nil {
+			return nil, err
+		}
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	f := newInflight()
+	if j != nil {
+		// Writes left by an earlier writer are in flight from the start
+		j.inflight = f
+		f.add(j.backlog())
+	}
+
 	w := &AsyncWriter{
 		layer:         layer,
-		queue:         make(chan writeOp, config.QueueSize),
+		lanes:         make([]*lane, config.Workers),
+		journal:       j,
 		workers:       config.Workers,
 		ctx:           ctx,
 		cancelFunc:    cancel,
+		closed:        make(chan struct{}),
+		inflight:      f,
 		config:        config,
 		metrics:       metricsCollector,
 		layerName:     layer.Name(),
@@ -90,24 +180,65 @@ func NewAsyncWriterWithMetrics(layer cache.CacheLayer, config AsyncWriterConfig,
 	}
 
 	// Start worker pool
+	laneSize := (config.QueueSize + config.Workers - 1) / config.Workers
 	for i := 0; i < config.Workers; i++ {
+		w.lanes[i] = newLane(laneSize)
 		w.wg.Add(1)
-		go w.worker()
+		go w.worker(w.lanes[i])
+	}
+
+	// Feed the workers from the journal
+	if j != nil {
+		w.wg.Add(1)
+		go w.feed()
 	}
 
 	// Start metrics reporter
 	go w.reportMetrics()
 
-	return w
+	return w, nil
 }
 
 // Write enqueues a write operation non-blockingly.
+// If a write of key is still waiting in the queue, it is replaced instead.
 // If the queue is full, it waits up to MaxWaitTime before dropping the write.
 // Returns ErrQueueFull if the write was dropped due to backpressure.
+// With a journal, the write is appended to it instead and is only dropped
+// (with ErrJournalFull) once the journal reaches JournalConfig.MaxBytes.
 func (w *AsyncWriter) Write(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return w.enqueue(ctx, &writeOp{
+		key:       key,
+		value:     value,
+		ttl:       ttl,
+		timestamp: time.Now(),
+	})
+}
+
+// WriteEntry enqueues a write of a value with its metadata, like Write.
+// The entry is stored with SetEntry if the layer implements cache.EntryLayer,
+// otherwise with Set until entry.ExpiresAt.
+func (w *AsyncWriter) WriteEntry(ctx context.Context, entry *cache.CacheEntry) error {
+	return w.enqueue(ctx, &writeOp{
+		key:       entry.Key,
+		entry:     entry,
+		timestamp: time.Now(),
+	})
+}
+
+// Delete enqueues a delete of key, like Write.
+func (w *AsyncWriter) Delete(ctx context.Context, key string) error {
+	return w.enqueue(ctx, &writeOp{
+		key:       key,
+		delete:    true,
+		timestamp: time.Now(),
+	})
+}
+
+// enqueue adds op to the queue, waiting up to MaxWaitTime if it's full.
+func (w *AsyncWriter) enqueue(ctx context.Context, op *writeOp) error {
 	// Check if writer is closed first
 	select {
-	case <-w.ctx.Done():
+	case <-w.closed:
 		return ErrWriterClosed
 	default:
 	}
@@ -119,114 +250,278 @@ func (w *AsyncWriter) Write(ctx context.Context, key string, value interface{},
 	default:
 	}
 
-	op := writeOp{
-		key:       key,
-		value:     value,
-		ttl:       ttl,
-		timestamp: time.Now(),
+	if w.journal != nil {
+		if err := w.journal.append(op); err != nil {
+			atomic.AddInt64(&w.droppedWrites, 1)
+			if errors.Is(err, ErrJournalFull) {
+				w.metrics.RecordWriteDropped(w.layerName)
+			}
+			return err
+		}
+		atomic.AddInt64(&w.totalWrites, 1)
+		return nil
 	}
 
 	// Try to enqueue with timeout
 	timer := time.NewTimer(w.config.MaxWaitTime)
 	defer timer.Stop()
 
-	select {
-	case w.queue <- op:
+	// Count op in flight before a worker can pick it up
+	w.inflight.add(1)
+
+	coalesced, err := w.push(ctx, op, timer.C)
+	switch err {
+	case nil:
 		atomic.AddInt64(&w.totalWrites, 1)
-		return nil
-	case <-timer.C:
+	case ErrQueueFull:
 		atomic.AddInt64(&w.droppedWrites, 1)
 		w.metrics.RecordWriteDropped(w.layerName)
-		return ErrQueueFull
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-w.ctx.Done():
-		return ErrWriterClosed
+	}
+	if coalesced || err != nil {
+		// Applied along with the write it replaced, or not at all
+		w.inflight.add(-1)
+	}
+	return err
+}
+
+// push adds op to the queue of its key's worker, waiting for room until
+// timeout fires (forever if timeout is nil). Reports whether op replaced a
+// write of its key waiting in the queue.
+func (w *AsyncWriter) push(ctx context.Context, op *writeOp, timeout <-chan time.Time) (bool, error) {
+	l := w.laneFor(op.key)
+	for {
+		coalesced, ok := l.offer(op)
+		if ok {
+			if coalesced {
+				atomic.AddInt64(&w.coalescedWrites, 1)
+			}
+			return coalesced, nil
+		}
+
+		select {
+		case <-l.space:
+		case <-timeout:
+			return false, ErrQueueFull
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-w.ctx.Done():
+			return false, ErrWriterClosed
+		}
+	}
+}
+
+// laneFor returns the queue of the worker applying the writes of key.
+func (w *AsyncWriter) laneFor(key string) *lane {
+	if len(w.lanes) == 1 {
+		return w.lanes[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return w.lanes[h.Sum32()%uint32(len(w.lanes))]
+}
+
+// feed moves writes from the journal to the queue, in journal order, until
+// the writer is closed. Writes not yet moved stay in the journal.
+func (w *AsyncWriter) feed() {
+	defer w.wg.Done()
+
+	for {
+		op, err := w.journal.next(w.ctx)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return
+			}
+			// Reading failed; try again shortly
+			select {
+			case <-time.After(w.config.RetryBackoff):
+				continue
+			case <-w.ctx.Done():
+				return
+			}
+		}
+
+		if _, err := w.push(w.ctx, op, nil); err != nil {
+			return
+		}
 	}
 }
 
-// worker processes write operations from the queue.
-func (w *AsyncWriter) worker() {
+// worker processes write operations from its queue until the writer is
+// closed. Close drains the queue first; writes left when it gives up are
+// abandoned.
+func (w *AsyncWriter) worker(l *lane) {
 	defer w.wg.Done()
 
 	for {
 		select {
-		case op, ok := <-w.queue:
+		case op, ok := <-l.queue:
 			if !ok {
 				// Queue closed
 				return
 			}
-			// Process write operation with timing
-			start := time.Now()
-			err := w.layer.Set(context.Background(), op.key, op.value, op.ttl)
-			duration := time.Since(start)
-
-			success := err == nil
-			w.metrics.RecordAsyncWrite(w.layerName, success, duration)
-
-			if err != nil {
-				atomic.AddInt64(&w.failedWrites, 1)
-				// In Phase 6, this will use structured logging
-				// For now, we silently count the failure
-			}
+			w.process(l.take(op))
 		case <-w.ctx.Done():
-			// Drain remaining items in queue before exiting
-			for {
-				select {
-				case op, ok := <-w.queue:
-					if !ok {
-						return
-					}
-					start := time.Now()
-					err := w.layer.Set(context.Background(), op.key, op.value, op.ttl)
-					duration := time.Since(start)
-
-					success := err == nil
-					w.metrics.RecordAsyncWrite(w.layerName, success, duration)
-
-					if err != nil {
-						atomic.AddInt64(&w.failedWrites, 1)
-					}
-				default:
-					return
-				}
-			}
+			return
 		}
 	}
 }
 
-// Flush waits for all pending writes to complete or until timeout.
-// Returns an error if timeout is exceeded.
-func (w *AsyncWriter) Flush(timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
+// process applies a write operation to the layer with timing. Failed
+// attempts are retried up to MaxAttempts times with exponential backoff;
+// writes that still fail are handed to OnDeadLetter. Writes interrupted by
+// Close giving up stay in flight (and in the journal, for the next writer).
+func (w *AsyncWriter) process(op writeOp) {
+	if w.ctx.Err() != nil {
+		return
+	}
+	start := time.Now()
 
+	attempts := 0
+	var err error
 	for {
-		if len(w.queue) == 0 {
-			return nil
+		attempts++
+		err = w.attempt(op)
+		if err != nil && w.ctx.Err() != nil {
+			return
 		}
+		if err == nil || attempts >= w.config.MaxAttempts || !retryable(err) {
+			break
+		}
+		if !w.sleep(w.backoff(attempts)) {
+			return
+		}
+	}
+	duration := time.Since(start)
+
+	success := err == nil
+	w.metrics.RecordAsyncWrite(w.layerName, success, duration)
+
+	if err != nil {
+		atomic.AddInt64(&w.failedWrites, 1)
+		atomic.AddInt64(&w.deadLetters, 1)
+		if w.config.OnDeadLetter != nil {
+			w.config.OnDeadLetter(newDeadLetter(op, attempts, err))
+		}
+	}
+
+	if w.journal != nil {
+		w.journal.ack(op.seqs)
+	} else {
+		w.inflight.add(-1)
+	}
+}
+
+// attempt applies op once, within OpTimeout.
+func (w *AsyncWriter) attempt(op writeOp) error {
+	ctx, cancel := context.WithTimeout(w.ctx, w.config.OpTimeout)
+	defer cancel()
+	return w.apply(ctx, op)
+}
+
+// backoff returns the delay before retry n (1 for the first retry):
+// RetryBackoff doubled n-1 times, capped at MaxRetryBackoff, minus a random
+// jitter of up to half of it so failing writers don't retry in lockstep.
+func (w *AsyncWriter) backoff(n int) time.Duration {
+	d := w.config.RetryBackoff
+	for i := 1; i < n && d < w.config.MaxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > w.config.MaxRetryBackoff {
+		d = w.config.MaxRetryBackoff
+	}
+	return d - rand.N(d/2+1)
+}
+
+// sleep waits for d, returning false if the writer is closed first.
+func (w *AsyncWriter) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+// retryable reports whether a failed write may succeed if tried again.
+func retryable(err error) bool {
+	return !errors.Is(err, cache.ErrInvalidKey) && !errors.Is(err, cache.ErrInvalidValue)
+}
+
+// apply performs the write, using SetEntry for entry writes when supported.
+func (w *AsyncWriter) apply(ctx context.Context, op writeOp) error {
+	if op.delete {
+		return w.layer.Delete(ctx, op.key)
+	}
+	if op.entry == nil {
+		return w.layer.Set(ctx, op.key, op.value, op.ttl)
+	}
+
+	if el, ok := w.layer.(cache.EntryLayer); ok {
+		return el.SetEntry(ctx, op.entry)
+	}
 
-		if time.Now().After(deadline) {
-			return ErrFlushTimeout
+	var ttl time.Duration
+	if !op.entry.ExpiresAt.IsZero() {
+		ttl = time.Until(op.entry.ExpiresAt)
+		if ttl <= 0 {
+			// Expired while queued
+			return nil
 		}
+	}
+	return w.layer.Set(ctx, op.key, op.entry.Value, ttl)
+}
 
-		time.Sleep(10 * time.Millisecond)
+// Flush waits until every write accepted so far was applied (or given up
+// on), including writes being applied and, with a journal, writes not yet
+// read from it. With a journal, the segments left with nothing to apply are
+// removed before Flush returns. Returns an error wrapping ErrFlushTimeout if ctx is done first.
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	if err := w.inflight.wait(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFlushTimeout, err)
 	}
+	return nil
 }
 
-// Close stops accepting new writes and waits for workers to complete.
-// Any writes in the queue will be processed before shutdown.
+// Close stops accepting new writes and waits until every accepted write was
+// applied (or given up on) before stopping the workers. With a journal,
+// this includes writes left by an earlier writer.
 func (w *AsyncWriter) Close() error {
+	_, err := w.CloseWithContext(context.Background())
+	return err
+}
+
+// CloseWithContext stops accepting new writes and drains them like Close
+// until ctx is done. Writes not applied by then are abandoned: attempts in
+// progress are cancelled and their count is returned along with ctx's error.
+// Abandoned journaled writes stay in the journal for the next writer.
+func (w *AsyncWriter) CloseWithContext(ctx context.Context) (abandoned int, err error) {
+	close(w.closed)
+
+	// Drain, unless ctx is done first
+	_ = w.inflight.wait(ctx)
+
 	// Stop metrics reporter
 	close(w.metricsStop)
 	w.metricsTicker.Stop()
 
-	// Signal workers to stop after draining queue
+	// Stop the workers and wait for them to finish
 	w.cancelFunc()
-
-	// Wait for all workers to finish
 	w.wg.Wait()
 
-	return nil
+	abandoned = w.inflight.count()
+	if abandoned > 0 {
+		err = ctx.Err()
+	}
+
+	if w.journal != nil {
+		if cerr := w.journal.close(); err == nil {
+			err = cerr
+		}
+	}
+	return abandoned, err
 }
 
 // reportMetrics periodically reports queue depth.
@@ -234,7 +529,7 @@ func (w *AsyncWriter) reportMetrics() {
 	for {
 		select {
 		case <-w.metricsTicker.C:
-			w.metrics.RecordQueueDepth(w.layerName, len(w.queue))
+			w.metrics.RecordQueueDepth(w.layerName, w.queueDepth())
 		case <-w.metricsStop:
 			return
 		}
@@ -243,10 +538,24 @@ func (w *AsyncWriter) reportMetrics() {
 
 // Stats returns current statistics about the async writer.
 func (w *AsyncWriter) Stats() AsyncWriterStats {
+	replayed, dropped := w.journal.stats()
 	return AsyncWriterStats{
-		QueueDepth:    len(w.queue),
-		DroppedWrites: atomic.LoadInt64(&w.droppedWrites),
-		TotalWrites:   atomic.LoadInt64(&w.totalWrites),
-		FailedWrites:  atomic.LoadInt64(&w.failedWrites),
+		QueueDepth:      w.queueDepth(),
+		DroppedWrites:   atomic.LoadInt64(&w.droppedWrites),
+		TotalWrites:     atomic.LoadInt64(&w.totalWrites),
+		FailedWrites:    atomic.LoadInt64(&w.failedWrites) + dropped,
+		CoalescedWrites: atomic.LoadInt64(&w.coalescedWrites),
+		DeadLetters:     atomic.LoadInt64(&w.deadLetters),
+		JournalBacklog:  w.journal.backlog(),
+		ReplayedWrites:  replayed,
+	}
+}
+
+// queueDepth returns the number of writes waiting in the workers' queues.
+func (w *AsyncWriter) queueDepth() int {
+	depth := 0
+	for _, l := range w.lanes {
+		depth += len(l.queue)
 	}
+	return depth
 }
@@ -2,6 +2,7 @@ package writer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -11,6 +12,22 @@ import (
 	"cache-chain/pkg/cache/mock"
 )
 
+// queueCap returns the capacity of the writer's queues.
+func queueCap(w *AsyncWriter) int {
+	total := 0
+	for _, l := range w.lanes {
+		total += cap(l.queue)
+	}
+	return total
+}
+
+// within returns a context that is done after d.
+func within(t *testing.T, d time.Duration) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	t.Cleanup(cancel)
+	return ctx
+}
+
 func TestNewAsyncWriter(t *testing.T) {
 	layer := &mock.MockLayer{}
 	config := AsyncWriterConfig{
@@ -30,8 +47,8 @@ func TestNewAsyncWriter(t *testing.T) {
 		t.Errorf("Expected 4 workers, got %d", writer.workers)
 	}
 
-	if cap(writer.queue) != 100 {
-		t.Errorf("Expected queue size 100, got %d", cap(writer.queue))
+	if got := queueCap(writer); got != 100 {
+		t.Errorf("Expected queue size 100, got %d", got)
 	}
 }
 
@@ -42,8 +59,8 @@ func TestNewAsyncWriter_Defaults(t *testing.T) {
 	writer := NewAsyncWriter(layer, config)
 	defer writer.Close()
 
-	if cap(writer.queue) != 1000 {
-		t.Errorf("Expected default queue size 1000, got %d", cap(writer.queue))
+	if got := queueCap(writer); got != 1000 {
+		t.Errorf("Expected default queue size 1000, got %d", got)
 	}
 
 	if writer.workers != 2 {
@@ -297,7 +314,7 @@ func TestAsyncWriter_Flush(t *testing.T) {
 	}
 
 	// Flush with reasonable timeout (longer to ensure all writes complete)
-	err := writer.Flush(1 * time.Second)
+	err := writer.Flush(within(t, time.Second))
 	if err != nil {
 		t.Fatalf("Flush failed: %v", err)
 	}
@@ -343,12 +360,86 @@ func TestAsyncWriter_FlushTimeout(t *testing.T) {
 	}
 
 	// Flush with short timeout should fail
-	err := writer.Flush(50 * time.Millisecond)
-	if err != ErrFlushTimeout {
+	err := writer.Flush(within(t, 50*time.Millisecond))
+	if !errors.Is(err, ErrFlushTimeout) {
 		t.Errorf("Expected ErrFlushTimeout, got %v", err)
 	}
 }
 
+func TestAsyncWriter_FlushWaitsForInFlight(t *testing.T) {
+	var applied atomic.Bool
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			time.Sleep(50 * time.Millisecond)
+			applied.Store(true)
+			return nil
+		},
+	}
+
+	writer := NewAsyncWriter(layer, AsyncWriterConfig{Workers: 1})
+	defer writer.Close()
+
+	writer.Write(context.Background(), "key", "value", time.Minute)
+
+	// Let the worker take the write off the queue
+	time.Sleep(10 * time.Millisecond)
+	if depth := writer.Stats().QueueDepth; depth != 0 {
+		t.Fatalf("Expected the write to be in progress, got queue depth %d", depth)
+	}
+
+	if err := writer.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if !applied.Load() {
+		t.Error("Expected Flush to wait for the write in progress")
+	}
+}
+
+func TestAsyncWriter_CloseWithContext(t *testing.T) {
+	var applied int64
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			if key == "block" {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			atomic.AddInt64(&applied, 1)
+			return nil
+		},
+	}
+
+	var deadLetters int64
+	writer := NewAsyncWriter(layer, AsyncWriterConfig{
+		Workers:      1,
+		OpTimeout:    time.Minute,
+		OnDeadLetter: func(DeadLetter) { atomic.AddInt64(&deadLetters, 1) },
+	})
+
+	ctx := context.Background()
+	writer.Write(ctx, "a", 1, time.Minute)
+	writer.Write(ctx, "block", 1, time.Minute)
+	writer.Write(ctx, "b", 1, time.Minute)
+	writer.Write(ctx, "c", 1, time.Minute)
+
+	abandoned, err := writer.CloseWithContext(within(t, 50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if abandoned != 3 {
+		t.Errorf("Expected 3 abandoned writes, got %d", abandoned)
+	}
+	if n := atomic.LoadInt64(&applied); n != 1 {
+		t.Errorf("Expected 1 applied write, got %d", n)
+	}
+	if n := atomic.LoadInt64(&deadLetters); n != 0 {
+		t.Errorf("Expected abandoned writes not to be dead letters, got %d", n)
+	}
+
+	if err := writer.Write(ctx, "d", 1, time.Minute); err != ErrWriterClosed {
+		t.Errorf("Expected ErrWriterClosed, got %v", err)
+	}
+}
+
 func TestAsyncWriter_Close(t *testing.T) {
 	var mu sync.Mutex
 	writes := make(map[string]interface{})
@@ -490,6 +581,189 @@ func TestAsyncWriter_Ordering(t *testing.T) {
 	}
 }
 
+func TestAsyncWriter_Delete(t *testing.T) {
+	var deleted int64
+	layer := &mock.MockLayer{
+		DeleteFunc: func(ctx context.Context, key string) error {
+			if key == "key" {
+				atomic.AddInt64(&deleted, 1)
+			}
+			return nil
+		},
+	}
+
+	writer := NewAsyncWriter(layer, AsyncWriterConfig{
+		QueueSize:   10,
+		Workers:     1,
+		MaxWaitTime: 10 * time.Millisecond,
+	})
+	defer writer.Close()
+
+	if err := writer.Delete(context.Background(), "key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// Wait for processing
+	time.Sleep(50 * time.Millisecond)
+
+	if atomic.LoadInt64(&deleted) != 1 {
+		t.Errorf("Expected 1 delete, got %d", atomic.LoadInt64(&deleted))
+	}
+}
+
+func TestAsyncWriter_Retries(t *testing.T) {
+	var attempts int64
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			if atomic.AddInt64(&attempts, 1) < 3 {
+				return fmt.Errorf("mock error")
+			}
+			return nil
+		},
+	}
+
+	writer := NewAsyncWriter(layer, AsyncWriterConfig{
+		QueueSize:    10,
+		Workers:      1,
+		MaxWaitTime:  10 * time.Millisecond,
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+	})
+	defer writer.Close()
+
+	if err := writer.Write(context.Background(), "key", "value", time.Minute); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Wait for processing
+	time.Sleep(50 * time.Millisecond)
+
+	if atomic.LoadInt64(&attempts) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", atomic.LoadInt64(&attempts))
+	}
+
+	stats := writer.Stats()
+	if stats.FailedWrites != 0 {
+		t.Errorf("Expected no failed writes after retries, got %d", stats.FailedWrites)
+	}
+}
+
+func TestAsyncWriter_Coalesce(t *testing.T) {
+	blocker := make(chan struct{})
+	var mu sync.Mutex
+	writes := make([]interface{}, 0)
+
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			if key == "block" {
+				<-blocker
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			writes = append(writes, value)
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, key string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			writes = append(writes, "deleted")
+			return nil
+		},
+	}
+
+	writer := NewAsyncWriter(layer, AsyncWriterConfig{
+		QueueSize:   10,
+		Workers:     1,
+		MaxWaitTime: 10 * time.Millisecond,
+	})
+	defer writer.Close()
+
+	// Hold the worker so the following writes wait in the queue
+	writer.Write(context.Background(), "block", "block", time.Minute)
+	time.Sleep(10 * time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		writer.Write(context.Background(), "key", i, time.Minute)
+	}
+	stats := writer.Stats()
+	if stats.QueueDepth != 1 {
+		t.Errorf("Expected 1 queued write for the key, got %d", stats.QueueDepth)
+	}
+	if stats.CoalescedWrites != 4 {
+		t.Errorf("Expected 4 coalesced writes, got %d", stats.CoalescedWrites)
+	}
+
+	writer.Delete(context.Background(), "key")
+	close(blocker)
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(writes) != 2 || writes[1] != "deleted" {
+		t.Errorf("Expected the block write and the latest op (delete), got %v", writes)
+	}
+}
+
+func TestAsyncWriter_PerKeyOrdering(t *testing.T) {
+	var mu sync.Mutex
+	last := make(map[string]int)
+	inFlight := make(map[string]bool)
+	var overlaps int64
+
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			mu.Lock()
+			if inFlight[key] {
+				atomic.AddInt64(&overlaps, 1)
+			}
+			inFlight[key] = true
+			mu.Unlock()
+
+			time.Sleep(time.Duration(value.(int)%3) * 100 * time.Microsecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight[key] = false
+			last[key] = value.(int)
+			return nil
+		},
+	}
+
+	writer := NewAsyncWriter(layer, AsyncWriterConfig{
+		QueueSize:   100,
+		Workers:     8,
+		MaxWaitTime: time.Second,
+	})
+	defer writer.Close()
+
+	keys := []string{"a", "b", "c", "d"}
+	for i := 0; i < 200; i++ {
+		for _, key := range keys {
+			if err := writer.Write(context.Background(), key, i, time.Minute); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+	}
+
+	if err := writer.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, key := range keys {
+		if last[key] != 199 {
+			t.Errorf("Expected latest value 199 for %s, got %d", key, last[key])
+		}
+	}
+	if n := atomic.LoadInt64(&overlaps); n != 0 {
+		t.Errorf("Expected writes of a key to never overlap, got %d overlaps", n)
+	}
+}
+
 func BenchmarkAsyncWriter_Write(b *testing.B) {
 	layer := &mock.MockLayer{
 		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
@@ -0,0 +1,50 @@
+package writer
+
+import (
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+// DeadLetter describes a write that was given up on after exhausting its
+// attempts (or failing with an error that retrying can't fix).
+type DeadLetter struct {
+	// Key is the key that was written
+	Key string
+
+	// Value and TTL are the value written with Write (nil for deletes and entries)
+	Value interface{}
+	TTL   time.Duration
+
+	// Entry is the entry written with WriteEntry (nil otherwise)
+	Entry *cache.CacheEntry
+
+	// Delete is set for deletes
+	Delete bool
+
+	// Attempts is how many times the write was tried
+	Attempts int
+
+	// Err is the error of the last attempt
+	Err error
+
+	// EnqueuedAt is when the write was requested
+	EnqueuedAt time.Time
+}
+
+// newDeadLetter describes op after its last failed attempt.
+func newDeadLetter(op writeOp, attempts int, err error) DeadLetter {
+	dl := DeadLetter{
+		Key:        op.key,
+		Entry:      op.entry,
+		Delete:     op.delete,
+		Attempts:   attempts,
+		Err:        err,
+		EnqueuedAt: op.timestamp,
+	}
+	if op.entry == nil && !op.delete {
+		dl.Value = op.value
+		dl.TTL = op.ttl
+	}
+	return dl
+}
@@ -0,0 +1,182 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/mock"
+	"cache-chain/pkg/metrics"
+)
+
+// deadLetters returns a DeadLetter callback and a func waiting for its first call.
+func deadLetters(t *testing.T) (func(DeadLetter), func() DeadLetter) {
+	ch := make(chan DeadLetter, 10)
+	return func(dl DeadLetter) { ch <- dl }, func() DeadLetter {
+		t.Helper()
+		select {
+		case dl := <-ch:
+			return dl
+		case <-time.After(time.Second):
+			t.Fatal("Expected a dead letter")
+			return DeadLetter{}
+		}
+	}
+}
+
+func TestAsyncWriter_Backoff(t *testing.T) {
+	w := NewAsyncWriter(&mock.MockLayer{}, AsyncWriterConfig{
+		RetryBackoff:    10 * time.Millisecond,
+		MaxRetryBackoff: 50 * time.Millisecond,
+	})
+	defer w.Close()
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{10, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			d := w.backoff(tt.retry)
+			if d < tt.max/2 || d > tt.max {
+				t.Fatalf("Expected backoff for retry %d in [%v, %v], got %v", tt.retry, tt.max/2, tt.max, d)
+			}
+		}
+	}
+}
+
+func TestAsyncWriter_DeadLetter(t *testing.T) {
+	var attempts int64
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			atomic.AddInt64(&attempts, 1)
+			return errors.New("mock error")
+		},
+	}
+
+	onDeadLetter, next := deadLetters(t)
+	w := NewAsyncWriter(layer, AsyncWriterConfig{
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+		OnDeadLetter: onDeadLetter,
+	})
+	defer w.Close()
+
+	w.Write(context.Background(), "key", "value", time.Minute)
+
+	dl := next()
+	if dl.Key != "key" || dl.Value != "value" || dl.TTL != time.Minute || dl.Delete {
+		t.Errorf("Expected dead letter for key=value, got %+v", dl)
+	}
+	if dl.Attempts != 3 || atomic.LoadInt64(&attempts) != 3 {
+		t.Errorf("Expected 3 attempts, got %d (layer saw %d)", dl.Attempts, atomic.LoadInt64(&attempts))
+	}
+	if dl.Err == nil || dl.EnqueuedAt.IsZero() {
+		t.Errorf("Expected error and enqueue time, got %+v", dl)
+	}
+
+	stats := w.Stats()
+	if stats.DeadLetters != 1 || stats.FailedWrites != 1 {
+		t.Errorf("Expected 1 dead letter and failed write, got %d and %d", stats.DeadLetters, stats.FailedWrites)
+	}
+}
+
+func TestAsyncWriter_OpTimeout(t *testing.T) {
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	onDeadLetter, next := deadLetters(t)
+	w := NewAsyncWriter(layer, AsyncWriterConfig{
+		OpTimeout:    10 * time.Millisecond,
+		OnDeadLetter: onDeadLetter,
+	})
+	defer w.Close()
+
+	w.Write(context.Background(), "key", "value", time.Minute)
+
+	if dl := next(); !errors.Is(dl.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", dl.Err)
+	}
+}
+
+func TestAsyncWriter_NonRetryable(t *testing.T) {
+	var attempts int64
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			atomic.AddInt64(&attempts, 1)
+			return cache.ErrInvalidValue
+		},
+	}
+
+	onDeadLetter, next := deadLetters(t)
+	w := NewAsyncWriter(layer, AsyncWriterConfig{
+		MaxAttempts:  5,
+		RetryBackoff: time.Millisecond,
+		OnDeadLetter: onDeadLetter,
+	})
+	defer w.Close()
+
+	w.Write(context.Background(), "key", "value", time.Minute)
+
+	if dl := next(); dl.Attempts != 1 || atomic.LoadInt64(&attempts) != 1 {
+		t.Errorf("Expected a single attempt, got %d", atomic.LoadInt64(&attempts))
+	}
+}
+
+func TestAsyncWriter_DeadLetterJournal(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var keys []string
+
+	layer := &mock.MockLayer{
+		DeleteFunc: func(ctx context.Context, key string) error {
+			return errors.New("mock error")
+		},
+	}
+	w, err := OpenAsyncWriter(layer, AsyncWriterConfig{
+		MaxAttempts:  2,
+		RetryBackoff: time.Millisecond,
+		OnDeadLetter: func(dl DeadLetter) {
+			mu.Lock()
+			defer mu.Unlock()
+			keys = append(keys, dl.Key)
+		},
+		Journal: &JournalConfig{Dir: dir},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+
+	w.Delete(context.Background(), "key")
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Dead letters are acked, so they aren't replayed after a restart
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected segments to be removed, got %v", files)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 1 || keys[0] != "key" {
+		t.Errorf("Expected dead letter for key, got %v", keys)
+	}
+}
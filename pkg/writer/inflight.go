@@ -0,0 +1,62 @@
+package writer
+
+import (
+	"context"
+	"sync"
+)
+
+// inflight counts the writes accepted but not yet applied (or given up on),
+// so Flush and Close can wait for the count to reach zero without polling.
+// Journaled writes are counted by the journal from append to ack, others
+// by the writer from enqueue until processed.
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // closed while n is zero
+}
+
+func newInflight() *inflight {
+	idle := make(chan struct{})
+	close(idle)
+	return &inflight{idle: idle}
+}
+
+// add changes the count by delta.
+func (f *inflight) add(delta int) {
+	if f == nil || delta == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.n == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.n += delta
+	if f.n <= 0 {
+		f.n = 0
+		close(f.idle)
+	}
+}
+
+// count returns the number of writes in flight.
+func (f *inflight) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.n
+}
+
+// wait blocks until no write is in flight or ctx is done.
+func (f *inflight) wait(ctx context.Context) error {
+	f.mu.Lock()
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
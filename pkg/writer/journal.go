@@ -0,0 +1,762 @@
+package writer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cache-chain/pkg/cache"
+)
+
+// SyncPolicy selects when journal appends are flushed to disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways flushes every write before Write returns (default)
+	SyncAlways SyncPolicy = iota
+
+	// SyncPeriodic flushes every SyncInterval; a crash may lose the writes
+	// of the last interval
+	SyncPeriodic
+
+	// SyncNever leaves flushing to the operating system
+	SyncNever
+)
+
+// JournalConfig configures the on-disk journal backing an AsyncWriter.
+//
+// With a journal, every write is appended to a segment file before Write
+// returns and is fed to the workers from there, so writes are never dropped
+// for backpressure and writes not yet applied when the process stops are
+// replayed by the next writer opened on the same directory. Segments are
+// removed once all their writes were applied. Keys longer than 65535 bytes
+// can't be journaled.
+type JournalConfig struct {
+	// Dir holds the segment files (required). It is created if missing and
+	// must not be shared by two open writers.
+	Dir string
+
+	// SegmentSize is the size at which a new segment file is started
+	// (default: 16MB, lowered to a quarter of MaxBytes if larger)
+	SegmentSize int64
+
+	// MaxBytes caps the size of the segment files. Writes that would exceed
+	// it are rejected with ErrJournalFull until applied writes free up
+	// space; the journal never drops accepted writes (default: 0, no limit)
+	MaxBytes int64
+
+	// Sync selects when appends are flushed to disk (default: SyncAlways)
+	Sync SyncPolicy
+
+	// SyncInterval is the flush period under SyncPeriodic (default: 100ms)
+	SyncInterval time.Duration
+
+	// Codec serializes values (default: cache.JSONCodec). Writes are
+	// applied with the values passed to Write; only writes replayed from an
+	// earlier run are decoded, into interface{} like values read from Redis.
+	Codec cache.Codec
+}
+
+// Record layout: body length and CRC-32C of the body (uint32s), then the body:
+// kind, sequence number and, for writes, timestamp, key, TTL and the value
+// encoded with cache.Encode (cache.EncodeEntry for entries).
+const (
+	recordHeaderSize = 8
+	maxRecordSize    = 256 << 20
+	maxKeySize       = math.MaxUint16
+
+	recordSet    byte = 1
+	recordEntry  byte = 2
+	recordDelete byte = 3
+	recordAck    byte = 4
+
+	segmentExt = ".journal"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errBadRecord marks a torn or corrupt record; reading a segment stops there.
+var errBadRecord = errors.New("writer: bad journal record")
+
+// record is a decoded journal record.
+type record struct {
+	kind byte
+	seq  uint64
+	ts   int64
+	key  string
+	ttl  time.Duration
+	data []byte
+}
+
+// segment is a journal file. Its records hold sequence numbers from base on.
+type segment struct {
+	id      uint64
+	path    string
+	base    uint64
+	size    int64 // bytes of valid records
+	pending int   // writes not yet acknowledged
+}
+
+// journal is an append-only log of writes, split into segments. Writes are
+// appended by Write, read back in order by the writer's feeder and
+// acknowledged once applied; segments whose writes were all acknowledged are
+// removed. Acknowledgements are appended too, so writes applied before a
+// crash aren't replayed over newer values.
+type journal struct {
+	config JournalConfig
+
+	mu       sync.Mutex
+	segments []*segment // oldest first
+	active   *segment   // segment being appended to, nil until the first append
+	file     *os.File   // write handle of active
+	nextID   uint64
+	nextSeq  uint64
+	pending  int
+	bytes    int64 // total size of the segments
+	dirty    bool
+	closed   bool
+	replayed int64
+	dropped  int64 // records that couldn't be decoded
+
+	// inflight follows pending for the writer's Flush (nil if unused)
+	inflight *inflight
+
+	// skip holds writes acknowledged in an earlier run that are still on disk
+	skip map[uint64]struct{}
+
+	// live holds the writes appended in this run until the feeder reads
+	// them, so they are applied with their original values
+	live map[uint64]*writeOp
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+
+	// Read cursor: rseg and readID (the first segment not yet read) are
+	// guarded by mu, the file and offset are used by the feeder only
+	rseg   *segment
+	readID uint64
+	rfile  *os.File
+	roff   int64
+}
+
+// openJournal opens the journal in config.Dir, finding the writes of earlier
+// runs that still need to be applied.
+func openJournal(config JournalConfig) (*journal, error) {
+	if config.Dir == "" {
+		return nil, errors.New("writer: journal directory is required")
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 16 << 20
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = 100 * time.Millisecond
+	}
+	if config.Codec == nil {
+		config.Codec = cache.JSONCodec{}
+	}
+	if config.MaxBytes > 0 && config.SegmentSize > config.MaxBytes/4 {
+		// Leave room for writes while the active segment can't be removed
+		config.SegmentSize = max(config.MaxBytes/4, 1)
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("writer: create journal directory: %w", err)
+	}
+
+	j := &journal{
+		config: config,
+		skip:   make(map[uint64]struct{}),
+		live:   make(map[uint64]*writeOp),
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := j.recover(); err != nil {
+		return nil, err
+	}
+
+	if config.Sync == SyncPeriodic {
+		go j.syncLoop()
+	} else {
+		close(j.done)
+	}
+
+	return j, nil
+}
+
+// recover scans the existing segments, counting the writes without an
+// acknowledgement. Reading a segment stops at its first bad record, which
+// is what a crash in the middle of an append leaves behind.
+func (j *journal) recover() error {
+	entries, err := os.ReadDir(j.config.Dir)
+	if err != nil {
+		return fmt.Errorf("writer: read journal directory: %w", err)
+	}
+
+	var ids []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+
+	acked := make(map[uint64]struct{})
+	writes := make([][]uint64, len(ids))
+	for i, id := range ids {
+		seg := &segment{id: id, path: j.segmentPath(id), base: j.nextSeq}
+		j.segments = append(j.segments, seg)
+		j.nextID = id + 1
+
+		f, err := os.Open(seg.path)
+		if err != nil {
+			return fmt.Errorf("writer: open journal segment: %w", err)
+		}
+		for {
+			rec, n, err := readRecord(f, seg.size)
+			if err != nil {
+				break
+			}
+			seg.size += n
+			if rec.seq >= j.nextSeq {
+				j.nextSeq = rec.seq + 1
+			}
+			if rec.kind == recordAck {
+				acked[rec.seq] = struct{}{}
+			} else {
+				writes[i] = append(writes[i], rec.seq)
+			}
+		}
+		f.Close()
+		j.bytes += seg.size
+	}
+
+	for i, seg := range j.segments {
+		if len(writes[i]) > 0 {
+			seg.base = writes[i][0]
+		}
+		for _, seq := range writes[i] {
+			if _, ok := acked[seq]; ok {
+				j.skip[seq] = struct{}{}
+				continue
+			}
+			seg.pending++
+		}
+		j.pending += seg.pending
+	}
+	j.replayed = int64(j.pending)
+
+	j.compact()
+	return nil
+}
+
+func (j *journal) segmentPath(id uint64) string {
+	return filepath.Join(j.config.Dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
+
+// append adds op to the journal, assigning its sequence number. Returns
+// ErrJournalFull if the journal has no room for it. If append fails, op
+// isn't applied.
+func (j *journal) append(op *writeOp) error {
+	if len(op.key) > maxKeySize {
+		return fmt.Errorf("%w: key too long to journal (max %d bytes)", cache.ErrInvalidKey, maxKeySize)
+	}
+
+	rec := record{key: op.key, ts: op.timestamp.UnixNano()}
+	switch {
+	case op.delete:
+		rec.kind = recordDelete
+	case op.entry != nil:
+		data, _, err := cache.EncodeEntry(j.config.Codec, cache.CompressionConfig{}, op.entry)
+		if err != nil {
+			return err
+		}
+		rec.kind, rec.data = recordEntry, data
+	default:
+		data, err := cache.Encode(j.config.Codec, op.value)
+		if err != nil {
+			return err
+		}
+		rec.kind, rec.ttl, rec.data = recordSet, op.ttl, data
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrWriterClosed
+	}
+
+	rec.seq = j.nextSeq
+	buf := encodeRecord(rec)
+	if j.config.MaxBytes > 0 && j.bytes+int64(len(buf)) > j.config.MaxBytes {
+		return ErrJournalFull
+	}
+	if err := j.write(buf, true); err != nil {
+		return fmt.Errorf("writer: journal append: %w", err)
+	}
+
+	switch j.config.Sync {
+	case SyncAlways:
+		if err := j.file.Sync(); err != nil {
+			// Take the record back: the next record overwrites it, and the
+			// feeder doesn't read past the valid records
+			j.active.size -= int64(len(buf))
+			j.bytes -= int64(len(buf))
+			return fmt.Errorf("writer: journal sync: %w", err)
+		}
+	case SyncPeriodic:
+		j.dirty = true
+	}
+
+	j.nextSeq++
+	j.active.pending++
+	j.pending++
+	j.inflight.add(1)
+	op.seqs = []uint64{rec.seq}
+	j.live[rec.seq] = op
+
+	select {
+	case j.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ack records that the writes with the given sequence numbers were applied
+// (or given up on) and removes the segments left with nothing to apply.
+// The writes stop counting as in flight once those segments are removed.
+func (j *journal) ack(seqs []uint64) {
+	if j == nil || len(seqs) == 0 {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return
+	}
+
+	acked := 0
+	for _, seq := range seqs {
+		// The last segment whose base doesn't exceed seq holds it
+		i := sort.Search(len(j.segments), func(i int) bool { return j.segments[i].base > seq }) - 1
+		if i < 0 {
+			continue
+		}
+		j.segments[i].pending--
+		j.pending--
+		acked++
+
+		// Losing an acknowledgement only means the write is applied again
+		_ = j.write(encodeRecord(record{kind: recordAck, seq: seq}), false)
+	}
+
+	j.compact()
+	j.inflight.add(-acked)
+}
+
+// write appends the encoded record buf to the active segment, starting a
+// new one when needed. Must be called with j.mu held.
+func (j *journal) write(buf []byte, rotate bool) error {
+	if j.active == nil || (rotate && j.active.size >= j.config.SegmentSize) {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	// Writing at the end of the valid records overwrites what a failed
+	// append may have left
+	if _, err := j.file.WriteAt(buf, j.active.size); err != nil {
+		return err
+	}
+	j.active.size += int64(len(buf))
+	j.bytes += int64(len(buf))
+	return nil
+}
+
+// rotate starts a new segment. Must be called with j.mu held.
+func (j *journal) rotate() error {
+	seg := &segment{id: j.nextID, base: j.nextSeq}
+	seg.path = j.segmentPath(seg.id)
+
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if j.config.Sync != SyncNever {
+		syncDir(j.config.Dir)
+	}
+
+	if j.file != nil {
+		_ = j.file.Sync()
+		_ = j.file.Close()
+	}
+	j.nextID++
+	j.segments = append(j.segments, seg)
+	j.active = seg
+	j.file = f
+	j.dirty = false
+	return nil
+}
+
+// compact removes the oldest segments once all their writes were applied.
+// Must be called with j.mu held.
+func (j *journal) compact() {
+	for len(j.segments) > 0 && j.segments[0] != j.active && j.segments[0].pending == 0 {
+		_ = os.Remove(j.segments[0].path)
+		j.bytes -= j.segments[0].size
+		j.segments = j.segments[1:]
+	}
+}
+
+// next returns the next write to apply, in journal order, waiting for one
+// to be appended if needed. Writes of this run are returned as appended;
+// writes of earlier runs are decoded from their records. Writes that can't
+// be decoded or expired while queued are acknowledged and skipped.
+func (j *journal) next(ctx context.Context) (*writeOp, error) {
+	for {
+		rec, err := j.read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.kind == recordAck {
+			continue
+		}
+		if j.skipped(rec.seq) {
+			continue
+		}
+
+		op := j.appended(rec.seq)
+		if op == nil {
+			if op, err = j.decode(rec); err != nil {
+				j.mu.Lock()
+				j.dropped++
+				j.mu.Unlock()
+			}
+		}
+		if op != nil && expired(op) {
+			op = nil
+		}
+		if op == nil {
+			j.ack([]uint64{rec.seq})
+			continue
+		}
+		return op, nil
+	}
+}
+
+// read returns the next record, moving through segments as they are finished.
+func (j *journal) read(ctx context.Context) (record, error) {
+	for {
+		j.mu.Lock()
+		if j.rseg == nil {
+			for _, seg := range j.segments {
+				if seg.id >= j.readID {
+					j.rseg = seg
+					break
+				}
+			}
+		}
+		seg := j.rseg
+		var size int64
+		finished := false
+		if seg != nil {
+			size = seg.size
+			finished = seg != j.active
+		}
+		j.mu.Unlock()
+
+		if seg != nil && j.roff < size {
+			if j.rfile == nil {
+				f, err := os.Open(seg.path)
+				if err != nil {
+					if !os.IsNotExist(err) {
+						return record{}, err
+					}
+					// Compacted before being read: it held nothing to apply
+					j.roff = size
+					continue
+				}
+				j.rfile = f
+			}
+			rec, n, err := readRecord(j.rfile, j.roff)
+			if err != nil {
+				// Records up to size were validated; give up on the segment
+				j.roff = size
+				continue
+			}
+			j.roff += n
+			return rec, nil
+		}
+
+		if seg != nil && finished {
+			// Segments other than the active one don't grow any more
+			j.closeReader()
+			j.mu.Lock()
+			j.rseg = nil
+			j.readID = seg.id + 1
+			j.mu.Unlock()
+			continue
+		}
+
+		select {
+		case <-j.notify:
+		case <-ctx.Done():
+			return record{}, ctx.Err()
+		}
+	}
+}
+
+// skipped reports (and forgets) writes acknowledged in an earlier run.
+func (j *journal) skipped(seq uint64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.skip[seq]; !ok {
+		return false
+	}
+	delete(j.skip, seq)
+	return true
+}
+
+// appended returns (and forgets) the write of seq if it was appended in
+// this run.
+func (j *journal) appended(seq uint64) *writeOp {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	op, ok := j.live[seq]
+	if !ok {
+		return nil
+	}
+	delete(j.live, seq)
+	return op
+}
+
+// decode rebuilds the write of rec from its encoded value.
+func (j *journal) decode(rec record) (*writeOp, error) {
+	op := &writeOp{key: rec.key, timestamp: time.Unix(0, rec.ts), seqs: []uint64{rec.seq}}
+
+	switch rec.kind {
+	case recordDelete:
+		op.delete = true
+	case recordEntry:
+		entry := &cache.CacheEntry{Key: rec.key}
+		if _, err := cache.DecodeEntry(j.config.Codec, rec.data, entry); err != nil {
+			return nil, err
+		}
+		op.entry = entry
+	case recordSet:
+		var value interface{}
+		if err := cache.Decode(j.config.Codec, rec.data, &value); err != nil {
+			return nil, err
+		}
+		op.value = value
+		op.ttl = rec.ttl
+	default:
+		return nil, errBadRecord
+	}
+	return op, nil
+}
+
+// expired shortens the TTL of op by the time it spent in the journal, so it
+// keeps its original expiry, and reports whether none is left.
+func expired(op *writeOp) bool {
+	if op.delete || op.entry != nil || op.ttl <= 0 {
+		return false
+	}
+	op.ttl -= time.Since(op.timestamp)
+	return op.ttl <= 0
+}
+
+// backlog returns the number of writes not yet applied.
+func (j *journal) backlog() int {
+	if j == nil {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.pending
+}
+
+// stats returns the number of writes replayed from earlier runs and of
+// writes dropped while reading the journal.
+func (j *journal) stats() (replayed, dropped int64) {
+	if j == nil {
+		return 0, 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.replayed, j.dropped
+}
+
+// syncLoop flushes appends every SyncInterval under SyncPeriodic.
+func (j *journal) syncLoop() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.mu.Lock()
+			if j.dirty && j.file != nil {
+				_ = j.file.Sync()
+				j.dirty = false
+			}
+			j.mu.Unlock()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// close flushes and closes the journal. If every write was applied, the
+// segments are removed.
+func (j *journal) close() error {
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return nil
+	}
+	j.closed = true
+	j.mu.Unlock()
+
+	close(j.stop)
+	<-j.done
+	j.closeReader()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var err error
+	if j.file != nil {
+		err = j.file.Sync()
+		if cerr := j.file.Close(); err == nil {
+			err = cerr
+		}
+		j.file = nil
+	}
+
+	if j.pending == 0 {
+		for _, seg := range j.segments {
+			_ = os.Remove(seg.path)
+		}
+		j.segments = nil
+		j.bytes = 0
+	}
+	j.live = nil
+	return err
+}
+
+func (j *journal) closeReader() {
+	if j.rfile != nil {
+		_ = j.rfile.Close()
+		j.rfile = nil
+	}
+	j.roff = 0
+}
+
+// encodeRecord serializes rec with its header.
+func encodeRecord(rec record) []byte {
+	size := 1 + 8
+	if rec.kind != recordAck {
+		size += 8 + 2 + len(rec.key) + 8 + len(rec.data)
+	}
+
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+size)
+	buf = append(buf, rec.kind)
+	buf = binary.BigEndian.AppendUint64(buf, rec.seq)
+	if rec.kind != recordAck {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(rec.ts))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(rec.key))) // checked by append
+		buf = append(buf, rec.key...)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(rec.ttl))
+		buf = append(buf, rec.data...)
+	}
+
+	body := buf[recordHeaderSize:]
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(body, crcTable))
+	return buf
+}
+
+// readRecord reads the record at off, returning it with its size on disk.
+// Truncated records and records failing their CRC return errBadRecord.
+func readRecord(r io.ReaderAt, off int64) (record, int64, error) {
+	var hdr [recordHeaderSize]byte
+	if _, err := r.ReadAt(hdr[:], off); err != nil {
+		if errors.Is(err, io.EOF) {
+			return record{}, 0, errBadRecord
+		}
+		return record{}, 0, err
+	}
+
+	size := binary.BigEndian.Uint32(hdr[0:4])
+	if size < 9 || size > maxRecordSize {
+		return record{}, 0, errBadRecord
+	}
+
+	body := make([]byte, size)
+	if _, err := r.ReadAt(body, off+recordHeaderSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			return record{}, 0, errBadRecord
+		}
+		return record{}, 0, err
+	}
+	if crc32.Checksum(body, crcTable) != binary.BigEndian.Uint32(hdr[4:8]) {
+		return record{}, 0, errBadRecord
+	}
+
+	rec := record{kind: body[0], seq: binary.BigEndian.Uint64(body[1:9])}
+	if rec.kind == recordAck {
+		return rec, recordHeaderSize + int64(size), nil
+	}
+
+	rest := body[9:]
+	if len(rest) < 10 {
+		return record{}, 0, errBadRecord
+	}
+	rec.ts = int64(binary.BigEndian.Uint64(rest[0:8]))
+	keyLen := int(binary.BigEndian.Uint16(rest[8:10]))
+	rest = rest[10:]
+	if len(rest) < keyLen+8 {
+		return record{}, 0, errBadRecord
+	}
+	rec.key = string(rest[:keyLen])
+	rec.ttl = time.Duration(binary.BigEndian.Uint64(rest[keyLen : keyLen+8]))
+	rec.data = rest[keyLen+8:]
+	return rec, recordHeaderSize + int64(size), nil
+}
+
+// syncDir flushes the directory entry of new segment files.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
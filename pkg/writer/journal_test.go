@@ -0,0 +1,470 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cache-chain/pkg/cache"
+	"cache-chain/pkg/cache/mock"
+	"cache-chain/pkg/metrics"
+)
+
+// recordingLayer returns a mock layer storing writes in values.
+func recordingLayer(values *sync.Map) *mock.MockLayer {
+	return &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			values.Store(key, value)
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, key string) error {
+			values.Delete(key)
+			return nil
+		},
+	}
+}
+
+// copyDir copies the segment files of src to dst, like the disk image a
+// crash leaves behind.
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dst, e.Name()), data, 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	return files
+}
+
+func TestJournal_RecordRoundTrip(t *testing.T) {
+	rec := record{kind: recordSet, seq: 42, ts: 7, key: "key", ttl: time.Minute, data: []byte("value")}
+	buf := encodeRecord(rec)
+
+	got, n, err := readRecord(bytes.NewReader(buf), 0)
+	if err != nil {
+		t.Fatalf("readRecord failed: %v", err)
+	}
+	if n != int64(len(buf)) {
+		t.Errorf("Expected size %d, got %d", len(buf), n)
+	}
+	if got.kind != rec.kind || got.seq != rec.seq || got.ts != rec.ts || got.key != rec.key ||
+		got.ttl != rec.ttl || string(got.data) != "value" {
+		t.Errorf("Expected %+v, got %+v", rec, got)
+	}
+
+	ack, _, err := readRecord(bytes.NewReader(encodeRecord(record{kind: recordAck, seq: 9})), 0)
+	if err != nil || ack.kind != recordAck || ack.seq != 9 {
+		t.Errorf("Expected ack 9, got %+v (%v)", ack, err)
+	}
+
+	// Torn record
+	if _, _, err := readRecord(bytes.NewReader(buf[:len(buf)-1]), 0); !errors.Is(err, errBadRecord) {
+		t.Errorf("Expected errBadRecord for torn record, got %v", err)
+	}
+
+	// Flipped bit
+	corrupt := append([]byte(nil), buf...)
+	corrupt[len(corrupt)-1] ^= 0x01
+	if _, _, err := readRecord(bytes.NewReader(corrupt), 0); !errors.Is(err, errBadRecord) {
+		t.Errorf("Expected errBadRecord for CRC mismatch, got %v", err)
+	}
+}
+
+func TestOpenAsyncWriter_JournalDirRequired(t *testing.T) {
+	_, err := OpenAsyncWriter(&mock.MockLayer{}, AsyncWriterConfig{Journal: &JournalConfig{}}, metrics.NoOpCollector{})
+	if err == nil {
+		t.Error("Expected error for journal without directory")
+	}
+}
+
+func TestAsyncWriter_Journal(t *testing.T) {
+	dir := t.TempDir()
+	var values sync.Map
+
+	w, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Workers: 1,
+		Journal: &JournalConfig{Dir: dir},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+
+	ctx := context.Background()
+	w.Write(ctx, "a", "1", time.Minute)
+	w.WriteEntry(ctx, &cache.CacheEntry{Key: "b", Value: "2", ExpiresAt: time.Now().Add(time.Minute)})
+	w.Write(ctx, "c", "3", time.Minute)
+	w.Delete(ctx, "c")
+
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if v, _ := values.Load("a"); v != "1" {
+		t.Errorf("Expected a=1, got %v", v)
+	}
+	if v, _ := values.Load("b"); v != "2" {
+		t.Errorf("Expected b=2, got %v", v)
+	}
+	if _, ok := values.Load("c"); ok {
+		t.Error("Expected c to be deleted")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected segments to be removed after a clean close, got %v", files)
+	}
+}
+
+func TestAsyncWriter_JournalReplay(t *testing.T) {
+	dir := t.TempDir()
+	crashed := t.TempDir()
+
+	// The layer applies 5 writes, then hangs on the 6th
+	release := make(chan struct{})
+	var calls int64
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			if atomic.AddInt64(&calls, 1) == 6 {
+				<-release
+			}
+			return nil
+		},
+	}
+
+	w, err := OpenAsyncWriter(layer, AsyncWriterConfig{
+		Workers: 1,
+		Journal: &JournalConfig{Dir: dir, SegmentSize: 256},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+
+	ctx := context.Background()
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9"}
+	for i, key := range keys {
+		if err := w.Write(ctx, key, float64(i), 0); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	w.Write(ctx, "k9", "latest", 0)
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&calls) < 6 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Kill the process: only what is on disk survives
+	copyDir(t, dir, crashed)
+	if files := segmentFiles(t, crashed); len(files) < 2 {
+		t.Fatalf("Expected several segments, got %v", files)
+	}
+
+	// A crash in the middle of an append leaves a torn record behind
+	files := segmentFiles(t, crashed)
+	last, err := os.OpenFile(files[len(files)-1], os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	last.Write(encodeRecord(record{kind: recordSet, seq: 99, key: "torn", data: []byte("x")})[:12])
+	last.Close()
+
+	var values sync.Map
+	restarted, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Workers: 1,
+		Journal: &JournalConfig{Dir: crashed},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	defer restarted.Close()
+
+	if err := restarted.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Writes applied before the crash aren't replayed; the others are, in order
+	for i, key := range keys {
+		v, ok := values.Load(key)
+		switch {
+		case i < 5 && ok:
+			t.Errorf("Expected %s not to be replayed, got %v", key, v)
+		case i >= 5 && i < 9 && v != float64(i):
+			t.Errorf("Expected %s=%d, got %v", key, i, v)
+		}
+	}
+	if v, _ := values.Load("k9"); v != "latest" {
+		t.Errorf("Expected latest value of k9, got %v", v)
+	}
+	if _, ok := values.Load("torn"); ok {
+		t.Error("Expected torn record to be ignored")
+	}
+
+	stats := restarted.Stats()
+	if stats.ReplayedWrites != 6 {
+		t.Errorf("Expected 6 replayed writes, got %d", stats.ReplayedWrites)
+	}
+	if stats.JournalBacklog != 0 {
+		t.Errorf("Expected empty backlog, got %d", stats.JournalBacklog)
+	}
+
+	close(release)
+	w.Close()
+}
+
+func TestAsyncWriter_JournalCompaction(t *testing.T) {
+	dir := t.TempDir()
+	var values sync.Map
+
+	w, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Workers: 1,
+		Journal: &JournalConfig{Dir: dir, SegmentSize: 128, Sync: SyncNever},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 50; i++ {
+		w.Write(ctx, "key", i, time.Minute)
+	}
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Flush returns once compacted: only the active segment is left
+	if files := segmentFiles(t, dir); len(files) != 1 {
+		t.Errorf("Expected 1 segment after compaction, got %d", len(files))
+	}
+}
+
+func TestAsyncWriter_JournalMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+
+	// The layer hangs until released, so writes pile up in the journal
+	release := make(chan struct{})
+	var values sync.Map
+	layer := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			<-release
+			values.Store(key, value)
+			return nil
+		},
+	}
+	w, err := OpenAsyncWriter(layer, AsyncWriterConfig{
+		Workers: 1,
+		Journal: &JournalConfig{Dir: dir, MaxBytes: 1024, Sync: SyncNever},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	ctx := context.Background()
+	accepted := 0
+	for i := 0; i < 100; i++ {
+		err := w.Write(ctx, fmt.Sprintf("key%d", i), "value", time.Minute)
+		if errors.Is(err, ErrJournalFull) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		accepted++
+	}
+	if accepted == 0 || accepted == 100 {
+		t.Fatalf("Expected the journal to fill up, accepted %d writes", accepted)
+	}
+	if stats := w.Stats(); stats.DroppedWrites != 1 || stats.JournalBacklog != accepted {
+		t.Errorf("Expected 1 dropped write and %d in the backlog, got %+v", accepted, stats)
+	}
+
+	// Accepted writes are all applied, which frees up space
+	close(release)
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	for i := 0; i < accepted; i++ {
+		if _, ok := values.Load(fmt.Sprintf("key%d", i)); !ok {
+			t.Errorf("Expected key%d to be applied", i)
+		}
+	}
+	if err := w.Write(ctx, "later", "value", time.Minute); err != nil {
+		t.Errorf("Expected write to be accepted once applied, got %v", err)
+	}
+}
+
+func TestAsyncWriter_JournalKeyTooLong(t *testing.T) {
+	w, err := OpenAsyncWriter(&mock.MockLayer{}, AsyncWriterConfig{
+		Journal: &JournalConfig{Dir: t.TempDir()},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	key := strings.Repeat("k", maxKeySize+1)
+	if err := w.Write(context.Background(), key, "value", time.Minute); !errors.Is(err, cache.ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	if backlog := w.Stats().JournalBacklog; backlog != 0 {
+		t.Errorf("Expected empty backlog, got %d", backlog)
+	}
+}
+
+func TestAsyncWriter_JournalKeepsValues(t *testing.T) {
+	type user struct{ Name string }
+
+	var values sync.Map
+	w, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Journal: &JournalConfig{Dir: t.TempDir()},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	w.Write(context.Background(), "user", user{Name: "ann"}, time.Minute)
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Written values are applied as passed, not decoded from the journal
+	if v, _ := values.Load("user"); v != (user{Name: "ann"}) {
+		t.Errorf("Expected the written value, got %#v", v)
+	}
+}
+
+func TestAsyncWriter_JournalSyncPeriodic(t *testing.T) {
+	dir := t.TempDir()
+	var values sync.Map
+
+	w, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Journal: &JournalConfig{Dir: dir, Sync: SyncPeriodic, SyncInterval: time.Millisecond},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+
+	w.Write(context.Background(), "key", "value", time.Minute)
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if v, _ := values.Load("key"); v != "value" {
+		t.Errorf("Expected value, got %v", v)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestAsyncWriter_JournalExpiredWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	// Journal a write whose TTL ran out before it could be applied
+	j, err := openJournal(JournalConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("openJournal failed: %v", err)
+	}
+	j.append(&writeOp{key: "old", value: "v", ttl: time.Millisecond, timestamp: time.Now().Add(-time.Second)})
+	j.append(&writeOp{key: "new", value: "v", ttl: time.Minute, timestamp: time.Now()})
+	j.close()
+
+	var values sync.Map
+	w, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Journal: &JournalConfig{Dir: dir},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, ok := values.Load("old"); ok {
+		t.Error("Expected expired write to be skipped")
+	}
+	if v, _ := values.Load("new"); v != "v" {
+		t.Errorf("Expected new=v, got %v", v)
+	}
+}
+
+func TestAsyncWriter_JournalCloseWithContext(t *testing.T) {
+	dir := t.TempDir()
+
+	// The layer hangs until the attempt is cancelled
+	stuck := &mock.MockLayer{
+		SetFunc: func(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	w, err := OpenAsyncWriter(stuck, AsyncWriterConfig{
+		Workers:   1,
+		OpTimeout: time.Minute,
+		Journal:   &JournalConfig{Dir: dir},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+
+	ctx := context.Background()
+	w.Write(ctx, "a", "1", time.Minute)
+	w.Write(ctx, "b", "2", time.Minute)
+
+	abandoned, err := w.CloseWithContext(within(t, 50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) || abandoned != 2 {
+		t.Fatalf("Expected 2 abandoned writes, got %d (%v)", abandoned, err)
+	}
+
+	// Abandoned writes are applied by the next writer
+	var values sync.Map
+	restarted, err := OpenAsyncWriter(recordingLayer(&values), AsyncWriterConfig{
+		Journal: &JournalConfig{Dir: dir},
+	}, metrics.NoOpCollector{})
+	if err != nil {
+		t.Fatalf("OpenAsyncWriter failed: %v", err)
+	}
+	if err := restarted.Flush(within(t, time.Second)); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if v, _ := values.Load(key); v != want {
+			t.Errorf("Expected %s=%s, got %v", key, want, v)
+		}
+	}
+
+	if abandoned, err := restarted.CloseWithContext(within(t, time.Second)); err != nil || abandoned != 0 {
+		t.Errorf("Expected clean close, got %d abandoned (%v)", abandoned, err)
+	}
+}
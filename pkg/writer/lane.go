@@ -0,0 +1,62 @@
+package writer
+
+import "sync"
+
+// lane is the queue of one worker. Writes are routed to lanes by key hash, so
+// the writes of a key are applied one at a time, in order.
+type lane struct {
+	queue chan *writeOp
+	space chan struct{} // signaled when the worker takes an op
+
+	mu      sync.Mutex
+	pending map[string]*writeOp // ops waiting in queue, by key
+}
+
+func newLane(size int) *lane {
+	return &lane{
+		queue:   make(chan *writeOp, size),
+		space:   make(chan struct{}, 1),
+		pending: make(map[string]*writeOp),
+	}
+}
+
+// offer adds op to the lane without blocking. If an op of the same key is
+// waiting in the queue, op replaces it in place (coalesced); otherwise op is
+// queued if there is room. Reports whether op was coalesced and whether it
+// was accepted.
+func (l *lane) offer(op *writeOp) (coalesced, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if queued, found := l.pending[op.key]; found {
+		// The replaced op is acknowledged along with op
+		op.seqs = append(queued.seqs, op.seqs...)
+		*queued = *op
+		return true, true
+	}
+
+	select {
+	case l.queue <- op:
+		l.pending[op.key] = op
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// take marks op as picked up by the worker, so later writes of its key are
+// queued behind it, and returns a copy that can be read without the lock.
+func (l *lane) take(op *writeOp) writeOp {
+	l.mu.Lock()
+	if l.pending[op.key] == op {
+		delete(l.pending, op.key)
+	}
+	taken := *op
+	l.mu.Unlock()
+
+	select {
+	case l.space <- struct{}{}:
+	default:
+	}
+	return taken
+}
@@ -15,6 +15,21 @@ type AsyncWriterStats struct {
 
 	// FailedWrites is the total number of writes that failed
 	FailedWrites int64
+
+	// CoalescedWrites is the number of writes that replaced a write of the
+	// same key still waiting in the queue (and are included in TotalWrites)
+	CoalescedWrites int64
+
+	// DeadLetters is the number of writes given up on after their last
+	// attempt failed (and are included in FailedWrites)
+	DeadLetters int64
+
+	// JournalBacklog is the number of journaled writes not yet applied
+	JournalBacklog int
+
+	// ReplayedWrites is the number of writes left in the journal by an
+	// earlier writer when this one was opened
+	ReplayedWrites int64
 }
 
 // Errors returned by async writer operations.
@@ -22,9 +37,14 @@ var (
 	// ErrQueueFull is returned when the write queue is full and MaxWaitTime exceeded
 	ErrQueueFull = errors.New("writer: queue full, write dropped")
 
+	// ErrJournalFull is returned when a write would take the journal past
+	// JournalConfig.MaxBytes; the write is dropped
+	ErrJournalFull = errors.New("writer: journal full, write dropped")
+
 	// ErrWriterClosed is returned when attempting to write to a closed writer
 	ErrWriterClosed = errors.New("writer: writer is closed")
 
-	// ErrFlushTimeout is returned when Flush() times out waiting for queue to drain
+	// ErrFlushTimeout is returned (wrapped with the context's error) when
+	// Flush() gives up waiting for the accepted writes to be applied
 	ErrFlushTimeout = errors.New("writer: flush timeout exceeded")
 )